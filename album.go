@@ -86,14 +86,14 @@ func (a *Album) GetName() string {
 
 // Get Album URI
 func (a *Album) GetURI() string {
-	return a.ID
+	return uriOf(EntityAlbum, a.ID)
 }
 
 // Get all Track URIs on Album
 func (a *Album) GetTrackURIs() []string {
 	uris := make([]string, 0)
 	for _, track := range a.Tracks.Items {
-		uris = append(uris, track.ID)
+		uris = append(uris, uriOf(EntityTrack, track.ID))
 	}
 	return uris
 }
@@ -102,7 +102,7 @@ func (a *Album) GetTrackURIs() []string {
 func (a *Album) GetArtistURIs() []string {
 	uris := make([]string, 0)
 	for _, artist := range a.Artists {
-		uris = append(uris, artist.ID)
+		uris = append(uris, uriOf(EntityArtist, artist.ID))
 	}
 	return uris
 }
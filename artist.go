@@ -32,7 +32,7 @@ func (a *Artist) GetName() string {
 
 // Get Artist URI
 func (a *Artist) GetURI() string {
-	return a.ID
+	return uriOf(EntityArtist, a.ID)
 }
 
 // Get Artist's Number of Followers
@@ -0,0 +1,58 @@
+package spotigo
+
+// Audiobook struct- maps to Spotify JSON response format by tag `json: "var_name"`
+// Struct generated by putting Spotify JSON data into JSON to Go struct generator at:
+// https://mholt.github.io/json-to-go/
+type Audiobook struct {
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	AvailableMarkets []string `json:"available_markets"`
+	Copyrights       []struct {
+		Text string `json:"text"`
+		Type string `json:"type"`
+	} `json:"copyrights"`
+	Description     string `json:"description"`
+	HTMLDescription string `json:"html_description"`
+	Edition         string `json:"edition"`
+	Explicit        bool   `json:"explicit"`
+	ExternalUrls    struct {
+		Spotify string `json:"spotify"`
+	} `json:"external_urls"`
+	Href   string `json:"href"`
+	ID     string `json:"id"`
+	Images []struct {
+		Height int    `json:"height"`
+		URL    string `json:"url"`
+		Width  int    `json:"width"`
+	} `json:"images"`
+	Languages []string `json:"languages"`
+	MediaType string   `json:"media_type"`
+	Name      string   `json:"name"`
+	Narrators []struct {
+		Name string `json:"name"`
+	} `json:"narrators"`
+	Publisher     string `json:"publisher"`
+	TotalChapters int    `json:"total_chapters"`
+	Type          string `json:"type"`
+	URI           string `json:"uri"`
+}
+
+// Get Audiobook Name
+func (a *Audiobook) GetName() string {
+	return a.Name
+}
+
+// Get Audiobook URI
+func (a *Audiobook) GetURI() string {
+	return uriOf(EntityAudiobook, a.ID)
+}
+
+// Get the names of the Audiobook's Authors
+func (a *Audiobook) GetAuthorNames() []string {
+	names := make([]string, 0)
+	for _, author := range a.Authors {
+		names = append(names, author.Name)
+	}
+	return names
+}
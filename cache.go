@@ -0,0 +1,434 @@
+package spotigo
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the responses of GET requests for WithCache
+// Values are opaque encoded responses; a Cache only has to keep them
+// Implementations must be safe for concurrent use
+type Cache interface {
+	// Get returns the value stored under key, or nil and no error if there is none
+	Get(key string) ([]byte, error)
+	// Set stores value under key, replacing any value already there
+	Set(key string, value []byte) error
+	// Delete removes the value stored under key, if any
+	Delete(key string) error
+}
+
+// Cache the responses of a Query's or User's GET requests in cache
+// Responses are stored by URL, including its market parameter, and
+// Accept-Language, and reused until their Cache-Control max-age runs out.
+// After that a response with an ETag is revalidated with If-None-Match, and
+// reused again if the API answers 304 Not Modified, as it does for unchanged
+// playlists. Responses marked no-store, requests for the current user's
+// data (under "me/") and requests for market "from_token" are not cached
+// Responses marked private, such as playlists, are stored under the identity
+// of the client that fetched them: a User's Spotify user ID, looked up once
+// with an extra request, or a Query's client ID. Other responses are shared
+// by every client using the cache, so Queries and Users can share one cache
+// without seeing each other's private data
+// Errors from cache are treated as misses, so a failing Cache slows requests
+// down rather than failing them
+func WithCache(cache Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// cachedResponse struct- a response as stored in a Cache
+type cachedResponse struct {
+	ContentType string    `json:"content_type,omitempty"`
+	ETag        string    `json:"etag,omitempty"`
+	Expires     time.Time `json:"expires"`
+	Body        []byte    `json:"body"`
+}
+
+// Wrap an HTTP client's transport so its GET requests go through cache
+// identity names the client, for storing private responses
+// A nil cache returns the client unchanged
+func cachingClient(c *http.Client, cache Cache, identity func(ctx context.Context) (string, error)) *http.Client {
+	if cache == nil {
+		return c
+	}
+	wrapped := *c
+	wrapped.Transport = &cachingTransport{cache: cache, next: c.Transport, identity: identity}
+	return &wrapped
+}
+
+// cachingTransport struct- an http.RoundTripper answering GET requests from
+// a Cache where it can
+type cachingTransport struct {
+	cache    Cache
+	next     http.RoundTripper
+	identity func(ctx context.Context) (string, error)
+}
+
+// Answer a request from the cache if its response is fresh, revalidate it if
+// it is stale, and otherwise send it, storing the response if allowed
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if !cacheable(req) {
+		return next.RoundTrip(req)
+	}
+	ctx := req.Context()
+	shared := cacheKey(req)
+	// Private responses are stored under a key of the client's own
+	private := ""
+	if id, err := t.identity(ctx); err == nil {
+		sum := sha256.Sum256([]byte(id))
+		private = hex.EncodeToString(sum[:16]) + " " + shared
+	}
+
+	key, entry := private, t.load(private)
+	if entry == nil {
+		key, entry = shared, t.load(shared)
+	}
+	if entry != nil && time.Now().Before(entry.Expires) {
+		noteCache(ctx, cacheHit)
+		return entry.response(req), nil
+	}
+
+	sent := req
+	if entry != nil && entry.ETag != "" {
+		sent = req.Clone(ctx)
+		sent.Header.Set("If-None-Match", entry.ETag)
+	}
+	res, err := next.RoundTrip(sent)
+	if err != nil {
+		return res, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && entry != nil && sent != req {
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		entry.Expires = expiresAt(res.Header)
+		if etag := res.Header.Get("ETag"); etag != "" {
+			entry.ETag = etag
+		}
+		t.store(key, entry)
+		noteCache(ctx, cacheRevalidated)
+		return entry.response(req), nil
+	}
+
+	noteCache(ctx, cacheMiss)
+	if res.StatusCode != http.StatusOK || !storable(res.Header) {
+		return res, nil
+	}
+	key = shared
+	if _, ok := cacheControl(res.Header)["private"]; ok {
+		if key = private; key == "" {
+			return res, nil
+		}
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	t.store(key, &cachedResponse{
+		ContentType: res.Header.Get("Content-Type"),
+		ETag:        res.Header.Get("ETag"),
+		Expires:     expiresAt(res.Header),
+		Body:        body,
+	})
+	return res, nil
+}
+
+// Read the entry stored under key; nil if there is none or it can't be read
+func (t *cachingTransport) load(key string) *cachedResponse {
+	if key == "" {
+		return nil
+	}
+	b, err := t.cache.Get(key)
+	if err != nil || b == nil {
+		return nil
+	}
+	entry := &cachedResponse{}
+	if json.Unmarshal(b, entry) != nil {
+		return nil
+	}
+	return entry
+}
+
+// Save an entry, ignoring failures
+func (t *cachingTransport) store(key string, entry *cachedResponse) {
+	if b, err := json.Marshal(entry); err == nil {
+		t.cache.Set(key, b)
+	}
+}
+
+// A 200 response to req rebuilt from a cache entry
+func (e *cachedResponse) response(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	if e.ETag != "" {
+		header.Set("ETag", e.ETag)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// Whether a request's response may come from or go into the cache
+func cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	if endpoint := endpointOf(req); endpoint == "me" || strings.HasPrefix(endpoint, "me/") {
+		return false
+	}
+	return req.URL.Query().Get("market") != "from_token"
+}
+
+// The key a request's response is stored under: its URL, with the query
+// parameters in a fixed order, and the languages asked for
+func cacheKey(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = u.Query().Encode()
+	u.Fragment = ""
+	key := u.String()
+	if lang := req.Header.Get("Accept-Language"); lang != "" {
+		key += " " + lang
+	}
+	return key
+}
+
+// Whether a response's Cache-Control allows storing it, and there is a reason
+// to: it stays fresh for a while or can be revalidated
+func storable(header http.Header) bool {
+	directives := cacheControl(header)
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+	return maxAge(header) > 0 || header.Get("ETag") != ""
+}
+
+// When a response stops being fresh
+func expiresAt(header http.Header) time.Time {
+	return time.Now().Add(maxAge(header))
+}
+
+// How long a response stays fresh, from its Cache-Control max-age
+// A response without max-age, or with no-cache, is stale straight away
+func maxAge(header http.Header) time.Duration {
+	directives := cacheControl(header)
+	if _, ok := directives["no-cache"]; ok {
+		return 0
+	}
+	seconds, err := strconv.Atoi(directives["max-age"])
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// The directives of a Cache-Control header, lowercased, with their values
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(value, ",") {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			name, arg := d, ""
+			if i := strings.IndexByte(d, '='); i >= 0 {
+				name, arg = d[:i], strings.Trim(d[i+1:], `"`)
+			}
+			directives[strings.ToLower(name)] = arg
+		}
+	}
+	return directives
+}
+
+// How the cache answered a request, recorded on its span
+type cacheOutcome string
+
+const (
+	cacheHit         cacheOutcome = "hit"
+	cacheRevalidated cacheOutcome = "revalidated"
+	cacheMiss        cacheOutcome = "miss"
+)
+
+// Record a request's cache outcome on its span
+func noteCache(ctx context.Context, outcome cacheOutcome) {
+	if s, ok := ctx.Value(requestSpanKey{}).(*requestSpan); ok {
+		s.setCache(string(outcome))
+	}
+}
+
+// MemoryCache is a Cache keeping values in memory, evicting the least
+// recently used when it grows past its bounds
+type MemoryCache struct {
+	maxBytes   int64
+	maxEntries int
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// memoryEntry struct- a value in a MemoryCache
+type memoryEntry struct {
+	key   string
+	value []byte
+}
+
+// Create a MemoryCache holding at most maxBytes of keys and values and at
+// most maxEntries values; 0 removes either bound
+func NewMemoryCache(maxBytes int64, maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxBytes:   maxBytes,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get the value stored under key, marking it as recently used
+func (c *MemoryCache) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*memoryEntry).value, nil
+}
+
+// Store value under key, evicting the least recently used values to make room
+// A value too big to fit at all is not stored
+func (c *MemoryCache) Set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(key)
+	size := int64(len(key) + len(value))
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, value: value})
+	c.size += size
+	for (c.maxBytes > 0 && c.size > c.maxBytes) || (c.maxEntries > 0 && c.order.Len() > c.maxEntries) {
+		c.remove(c.order.Back().Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Delete the value stored under key
+func (c *MemoryCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key)
+	return nil
+}
+
+// Remove key's entry; c.mu must be held
+func (c *MemoryCache) remove(key string) {
+	el, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	e := el.Value.(*memoryEntry)
+	c.size -= int64(len(e.key) + len(e.value))
+	c.order.Remove(el)
+	delete(c.entries, key)
+}
+
+// Number of values stored and their total size in bytes, keys included
+func (c *MemoryCache) Len() (entries int, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len(), c.size
+}
+
+// DiskCache is a Cache keeping each value in a file in a directory
+// Files are named by a hash of their key and created with mode 0600, since
+// cached playlists may be private. Nothing is evicted; clear the directory
+// to reclaim space
+type DiskCache struct {
+	dir string
+}
+
+// Create a DiskCache storing files in dir, which is created if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// The file key's value is stored in
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// Read the value stored under key
+func (c *DiskCache) Get(key string) ([]byte, error) {
+	b, err := ioutil.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// Store value under key, replacing its file atomically
+func (c *DiskCache) Set(key string, value []byte) error {
+	path := c.path(key)
+	tmp, err := ioutil.TempFile(c.dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete the file of key's value
+func (c *DiskCache) Delete(key string) error {
+	err := os.Remove(c.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
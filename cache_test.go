@@ -0,0 +1,227 @@
+package spotigo_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+)
+
+// Middleware letting responses to track requests be cached for a minute, as
+// the fake server doesn't say
+func cacheTracks(next http.RoundTripper) http.RoundTripper {
+	return spotigo.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		res, err := next.RoundTrip(req)
+		if err == nil && strings.Contains(req.URL.Path, "/tracks/") {
+			res.Header.Set("Cache-Control", "public, max-age=60")
+		}
+		return res, err
+	})
+}
+
+func TestCacheHit(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	tr := &recordingTracer{}
+	cache := spotigo.NewMemoryCache(0, 0)
+	q := newQuery(t, srv, spotigo.WithCache(cache), spotigo.WithMiddleware(cacheTracks), spotigo.WithTracer(tr))
+
+	for i := 0; i < 3; i++ {
+		track, err := q.GetTrackByURI("t1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if track.Name != "Disco Man" {
+			t.Errorf("request %d: got %q, want Disco Man", i, track.Name)
+		}
+	}
+	if n := countRequests(srv, "GET /v1/tracks/t1"); n != 1 {
+		t.Errorf("%d requests reached the server, want 1", n)
+	}
+	if got := tr.attr("HTTP GET", spotigo.AttrCache); len(got) != 3 || got[0] != "miss" || got[1] != "hit" || got[2] != "hit" {
+		t.Errorf("cache outcomes %v, want [miss hit hit]", got)
+	}
+
+	// Another Query sharing the cache reuses the public response
+	other := newQuery(t, srv, spotigo.WithCache(cache), spotigo.WithMiddleware(cacheTracks))
+	if _, err := other.GetTrackByURI("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRequests(srv, "GET /v1/tracks/t1"); n != 1 {
+		t.Errorf("%d requests reached the server, want 1", n)
+	}
+}
+
+func TestCacheRevalidatesWithETag(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	srv.AddPlaylist(spotigo.Playlist{ID: "p1", Name: "Mix"}, "t1")
+	tr := &recordingTracer{}
+	q := newQuery(t, srv, spotigo.WithCache(spotigo.NewMemoryCache(0, 0)), spotigo.WithTracer(tr))
+
+	first, err := q.GetPlaylistByURI("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := q.GetPlaylistByURI("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Name != first.Name || second.Name != "Mix" {
+		t.Errorf("revalidated playlist %q, first fetched as %q", second.Name, first.Name)
+	}
+	if n := countRequests(srv, "GET /v1/playlists/p1"); n != 2 {
+		t.Errorf("%d requests, want 2: playlists are private, max-age=0", n)
+	}
+	if got := tr.attr("HTTP GET", spotigo.AttrCache); len(got) != 2 || got[0] != "miss" || got[1] != "revalidated" {
+		t.Errorf("cache outcomes %v, want [miss revalidated]", got)
+	}
+}
+
+func TestCacheKeepsPrivateResponsesApart(t *testing.T) {
+	srv := newServer(t)
+	srv.AddPlaylist(spotigo.Playlist{ID: "p1", Name: "Mix"})
+	cache := spotigo.NewMemoryCache(0, 0)
+	newApp := func(client string, tr spotigo.Tracer) spotigo.Query {
+		q, err := spotigo.NewQuery(client, "secret", testOptions(srv, spotigo.WithCache(cache), spotigo.WithTracer(tr))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q
+	}
+	trA, trB := &recordingTracer{}, &recordingTracer{}
+	a, b := newApp("app-a", trA), newApp("app-b", trB)
+
+	for _, q := range []spotigo.Query{a, b, a} {
+		if _, err := q.GetPlaylistByURI("p1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := trA.attr("HTTP GET", spotigo.AttrCache); len(got) != 2 || got[0] != "miss" || got[1] != "revalidated" {
+		t.Errorf("app-a cache outcomes %v, want [miss revalidated]", got)
+	}
+	if got := trB.attr("HTTP GET", spotigo.AttrCache); len(got) != 1 || got[0] != "miss" {
+		t.Errorf("app-b cache outcomes %v, want [miss]", got)
+	}
+	if entries, _ := cache.Len(); entries != 2 {
+		t.Errorf("%d cache entries, want one per app", entries)
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := spotigo.NewMemoryCache(0, 2)
+	c.Set("a", []byte("1"))
+	c.Set("b", []byte("2"))
+	c.Get("a")
+	c.Set("c", []byte("3"))
+
+	for key, want := range map[string]string{"a": "1", "b": "", "c": "3"} {
+		if got, err := c.Get(key); err != nil || string(got) != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+	if entries, size := c.Len(); entries != 2 || size != 4 {
+		t.Errorf("Len() = %d, %d, want 2, 4", entries, size)
+	}
+}
+
+func TestMemoryCacheMaxBytes(t *testing.T) {
+	c := spotigo.NewMemoryCache(10, 0)
+	c.Set("a", []byte("1234"))
+	c.Set("b", []byte("1234"))
+	c.Set("c", []byte("12"))
+	if got, _ := c.Get("a"); got != nil {
+		t.Errorf("a not evicted: %q", got)
+	}
+	if entries, size := c.Len(); entries != 2 || size != 8 {
+		t.Errorf("Len() = %d, %d, want 2, 8", entries, size)
+	}
+
+	// Replacing a value frees its old size; a value too big isn't stored
+	c.Set("b", []byte("1"))
+	c.Set("huge", bytes.Repeat([]byte("x"), 20))
+	if got, _ := c.Get("huge"); got != nil {
+		t.Error("stored a value bigger than the cache")
+	}
+	if entries, size := c.Len(); entries != 2 || size != 5 {
+		t.Errorf("Len() = %d, %d, want 2, 5", entries, size)
+	}
+
+	c.Delete("b")
+	if entries, size := c.Len(); entries != 1 || size != 3 {
+		t.Errorf("after Delete, Len() = %d, %d, want 1, 3", entries, size)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c, err := spotigo.NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := c.Get("missing"); got != nil || err != nil {
+		t.Errorf("Get of a missing key = %q, %v", got, err)
+	}
+	if err := c.Set("k", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("k", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Get("k"); err != nil || string(got) != "v2" {
+		t.Errorf("Get = %q, %v, want v2", got, err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("%d files, want 1", len(files))
+	}
+	info, err := files[0].Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("file mode %v, want 0600", mode)
+	}
+
+	if err := c.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete("k"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+	if got, _ := c.Get("k"); got != nil {
+		t.Errorf("Get after Delete = %q", got)
+	}
+}
+
+// failingCache struct- a Cache whose every operation fails
+type failingCache struct{}
+
+func (failingCache) Get(string) ([]byte, error) { return nil, errors.New("cache down") }
+func (failingCache) Set(string, []byte) error   { return errors.New("cache down") }
+func (failingCache) Delete(string) error        { return errors.New("cache down") }
+
+func TestCacheErrorsAreMisses(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	q := newQuery(t, srv, spotigo.WithCache(failingCache{}), spotigo.WithMiddleware(cacheTracks))
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.GetTrackByURI("t1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countRequests(srv, "GET /v1/tracks/t1"); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
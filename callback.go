@@ -0,0 +1,209 @@
+package spotigo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/browser"
+	"golang.org/x/oauth2"
+)
+
+// Result of a login, delivered by the callback handler
+type authResult struct {
+	tok *oauth2.Token
+	err error
+}
+
+// Open a URL in the user's browser; replaced in tests
+var openBrowser = browser.OpenURL
+
+// Generate a random state to protect a login from CSRF
+func newState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Log in by opening the authorization URL in a browser and waiting for the
+// redirect to the callback server listening on the redirect URL
+// Logins on the same address share one server, which routes each redirect by
+// its state; the server is shut down once no login is waiting on it
+func (a authenticator) browserLogin(ctx context.Context, state string, timeout time.Duration, authOpts, exchangeOpts []oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	redirect, err := url.Parse(a.config.RedirectURL)
+	if err != nil || redirect.Scheme != "http" || redirect.Hostname() == "" {
+		return nil, invalidInput("redirect URL %q must be an http URL to listen on", a.config.RedirectURL)
+	}
+
+	addr := listenAddr(redirect)
+	c, err := acquireCallbackServer(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseCallbackServer(addr, c)
+
+	// Port 0 picks a free port, which the redirect URL must then name
+	if redirect.Port() == "0" {
+		redirect.Host = c.ln.Addr().String()
+		a.config.RedirectURL = redirect.String()
+	}
+
+	login := &pendingLogin{
+		path: callbackPath(redirect),
+		exchange: func(r *http.Request) (*oauth2.Token, error) {
+			return a.token(state, r, exchangeOpts...)
+		},
+		done: make(chan authResult, 1),
+	}
+	c.add(state, login)
+	defer c.remove(state)
+
+	// Use if you want login dialog to show every time
+	// url := a.authURLWithDialog(state, authOpts...)
+
+	// Use if you want login dialog to show only when necessary
+	openBrowser(a.authURL(state, authOpts...))
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	// wait for auth to complete
+	select {
+	case res := <-login.done:
+		return res.tok, res.err
+	case <-expired:
+		return nil, ErrLoginTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pendingLogin struct- a login waiting for its redirect
+type pendingLogin struct {
+	path     string
+	exchange func(r *http.Request) (*oauth2.Token, error)
+	done     chan authResult
+}
+
+// callbackServer struct- a local server receiving login redirects on one address
+type callbackServer struct {
+	srv *http.Server
+	ln  net.Listener
+
+	// Logins using the server, guarded by callbackMu
+	users int
+
+	mu     sync.Mutex
+	logins map[string]*pendingLogin
+}
+
+// Callback servers by the address they were asked to listen on
+var (
+	callbackMu      sync.Mutex
+	callbackServers = make(map[string]*callbackServer)
+)
+
+// Get the callback server for addr, starting it if no other login is using it
+func acquireCallbackServer(addr string) (*callbackServer, error) {
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+
+	c, ok := callbackServers[addr]
+	if !ok {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("spotify: couldn't start callback server: %w", err)
+		}
+		c = &callbackServer{ln: ln, logins: make(map[string]*pendingLogin)}
+		c.srv = &http.Server{Handler: c}
+		go c.srv.Serve(ln)
+		callbackServers[addr] = c
+	}
+	c.users++
+	return c, nil
+}
+
+// Release a callback server, shutting it down if no other login is using it
+func releaseCallbackServer(addr string, c *callbackServer) {
+	callbackMu.Lock()
+	c.users--
+	if c.users > 0 {
+		callbackMu.Unlock()
+		return
+	}
+	// Free the address now, so a new login can listen on it straight away
+	delete(callbackServers, addr)
+	c.ln.Close()
+	callbackMu.Unlock()
+
+	// Let the response to the last callback finish before closing
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.srv.Shutdown(ctx)
+}
+
+// Route redirects carrying state to login
+func (c *callbackServer) add(state string, login *pendingLogin) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logins[state] = login
+}
+
+// Stop routing redirects carrying state
+func (c *callbackServer) remove(state string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.logins, state)
+}
+
+// Hand a redirect to the login that its state belongs to
+func (c *callbackServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	login, ok := c.logins[r.URL.Query().Get("state")]
+	c.mu.Unlock()
+
+	// Requests without a known state weren't started by a login
+	if !ok || r.URL.Path != login.path {
+		http.Error(w, "Unknown login", http.StatusBadRequest)
+		return
+	}
+
+	tok, err := login.exchange(r)
+	if err != nil {
+		http.Error(w, "Couldn't get token", http.StatusForbidden)
+	} else {
+		fmt.Fprintf(w, "Login Completed!")
+	}
+	select {
+	case login.done <- authResult{tok: tok, err: err}:
+	default:
+	}
+}
+
+// Address the callback server for a redirect URL listens on
+func listenAddr(redirect *url.URL) string {
+	if redirect.Port() == "" {
+		return net.JoinHostPort(redirect.Hostname(), "80")
+	}
+	return redirect.Host
+}
+
+// Path the callback server serves, "/" if the redirect URL has none
+func callbackPath(redirect *url.URL) string {
+	if redirect.Path == "" {
+		return "/"
+	}
+	return redirect.Path
+}
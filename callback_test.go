@@ -0,0 +1,105 @@
+package spotigo_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+)
+
+// A free local port to listen on
+func freePort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+func TestConcurrentBrowserLogins(t *testing.T) {
+	srv := newServer(t)
+	addr := "127.0.0.1:" + freePort(t)
+
+	// The browser approves the logins once both are waiting, the last first
+	var mu sync.Mutex
+	opened := make([]string, 0)
+	spotigo.SetOpenBrowser(t, func(authURL string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		opened = append(opened, authURL)
+		if len(opened) == 2 {
+			go func() {
+				for i := len(opened) - 1; i >= 0; i-- {
+					res, err := srv.Client().Get(opened[i])
+					if err != nil {
+						t.Error(err)
+						continue
+					}
+					res.Body.Close()
+					if res.StatusCode != http.StatusOK {
+						t.Errorf("callback %d: status %d", i, res.StatusCode)
+					}
+				}
+			}()
+		}
+		return nil
+	})
+
+	scopes := [][]string{{spotigo.ScopeUserReadPrivate}, {spotigo.ScopeUserLibraryRead}}
+	users := make([]*spotigo.User, len(scopes))
+	errs := make([]error, len(scopes))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := range scopes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			users[i], errs[i] = spotigo.NewUserPKCEContext(ctx, "id", testOptions(srv,
+				spotigo.WithRedirectURL("http://"+addr+"/callback"), spotigo.WithScopes(scopes[i]...))...)
+		}(i)
+	}
+	wg.Wait()
+
+	// Each login received its own code, so its token has the scopes it asked for
+	for i, u := range users {
+		if errs[i] != nil {
+			t.Errorf("login %d: %v", i, errs[i])
+			continue
+		}
+		tok, err := u.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := spotigo.TokenScopes(tok); !reflect.DeepEqual(got, scopes[i]) {
+			t.Errorf("login %d: scopes %v, want %v", i, got, scopes[i])
+		}
+	}
+
+	// The shared callback server is gone once both logins are done
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Errorf("callback address still in use: %v", err)
+	} else {
+		ln.Close()
+	}
+}
+
+func TestBrowserLoginTimeout(t *testing.T) {
+	srv := newServer(t)
+	spotigo.SetOpenBrowser(t, func(string) error { return nil })
+
+	_, err := spotigo.NewUserPKCE("id", testOptions(srv,
+		spotigo.WithRedirectURL("http://127.0.0.1:0/callback"), spotigo.WithAuthTimeout(20*time.Millisecond))...)
+	if err != spotigo.ErrLoginTimeout {
+		t.Errorf("got %v, want ErrLoginTimeout", err)
+	}
+}
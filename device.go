@@ -1,8 +1,7 @@
 package spotigo
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"net/http"
 )
 
@@ -25,20 +24,42 @@ type Device struct {
 }
 
 // Return a user's available playback devices
-func (u *User) GetPlaybackDevices() ([]Device, bool) {
+func (u *User) GetPlaybackDevices() ([]Device, error) {
+	return u.GetPlaybackDevicesContext(context.Background())
+}
+
+// GetPlaybackDevicesContext is GetPlaybackDevices with a context
+func (u *User) GetPlaybackDevicesContext(ctx context.Context) (items []Device, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetPlaybackDevices")
+	defer func() { span.endItems(err, len(items)) }()
+
+	if err := u.checkScopes("GetPlaybackDevices"); err != nil {
+		return nil, err
+	}
 	var result struct {
 		Devices []Device `json:"devices"`
 	}
 
 	reqURL := u.baseURL + "me/player/devices"
 
-	ok := u.sendGetRequest(reqURL, &result)
+	err = u.sendGetRequest(ctx, reqURL, &result)
 
-	return result.Devices, ok
+	return result.Devices, err
 }
 
 // Transfer playback between devices
-func (u *User) TransferPlayback(device interface{}, play bool) bool {
+func (u *User) TransferPlayback(device interface{}, play bool) error {
+	return u.TransferPlaybackContext(context.Background(), device, play)
+}
+
+// TransferPlaybackContext is TransferPlayback with a context
+func (u *User) TransferPlaybackContext(ctx context.Context, device interface{}, play bool) (err error) {
+	ctx, span := u.engine.startCall(ctx, "TransferPlayback")
+	defer span.end(&err)
+
+	if err := u.checkScopes("TransferPlayback"); err != nil {
+		return err
+	}
 
 	deviceID := ""
 	switch v := device.(type) {
@@ -48,7 +69,7 @@ func (u *User) TransferPlayback(device interface{}, play bool) bool {
 		deviceID = string(v.ID)
 	// Base Case: Invalid Type
 	default:
-		return false
+		return invalidInput("expected string or Device, got %T", device)
 	}
 
 	// Source: https://github.com/zmb3/spotify/
@@ -59,22 +80,7 @@ func (u *User) TransferPlayback(device interface{}, play bool) bool {
 		DeviceID: []string{deviceID},
 		Play:     play,
 	}
-	buf := new(bytes.Buffer)
-	err := json.NewEncoder(buf).Encode(reqData)
 	/// End Source
 
-	if err != nil {
-		return false
-	}
-
-	req, err := http.NewRequest(http.MethodPut, u.baseURL+"me/player", buf)
-	if err != nil {
-		return false
-	}
-	err = u.execute(req, nil, http.StatusNoContent)
-	if err != nil {
-		return false
-	}
-
-	return true
+	return u.engine.do(ctx, http.MethodPut, u.baseURL+"me/player", reqData, nil)
 }
@@ -0,0 +1,162 @@
+package spotigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// engine struct- sends the Web API requests of a Query or User
+// Both get the same status handling, JSON and error decoding and headers;
+// pacing, retries and any other transport concerns live in its HTTP client
+type engine struct {
+	http           *http.Client
+	acceptLanguage string
+	tracer         Tracer
+	metrics        MetricsCollector
+
+	// Supplies a request's access token; nil sends requests without one
+	token func(ctx context.Context) (string, error)
+	// Drops a token the API rejected, so that a retry gets a new one
+	invalidate func(token string)
+	// Names the client whose credentials the requests are sent with, for
+	// caching private responses; nil if responses are never private to it
+	identity func(ctx context.Context) (string, error)
+}
+
+// Build the engine for a client that sends requests with c
+// Requests go through the rate limiter, then the metrics collector and the
+// middleware; GET requests the cache can answer go no further than it.
+// Set the engine's token and invalidate to authorize its requests
+func newEngine(c *http.Client, o options) *engine {
+	transport := o.wrapTransport(c.Transport)
+	if o.metrics != nil {
+		transport = metricsMiddleware(o.metrics)(transport)
+	}
+	retried := func(req *http.Request, status int) {
+		countRetry(req.Context())
+		if o.metrics != nil {
+			o.metrics.ObserveRetry(req.Method, endpointOf(req), status)
+		}
+	}
+	wrapped := *c
+	wrapped.Transport = transport
+
+	tracer := o.tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+	e := &engine{acceptLanguage: o.acceptLanguage, tracer: tracer, metrics: o.metrics}
+	e.http = cachingClient(o.rateLimiter.client(&wrapped, retried), o.cache, e.cacheIdentity)
+	return e
+}
+
+// The identity of the engine's client, for caching private responses
+func (e *engine) cacheIdentity(ctx context.Context) (string, error) {
+	if e.identity == nil {
+		return "", errors.New("spotify: no client identity")
+	}
+	return e.identity(ctx)
+}
+
+// Send a GET request and decode the JSON response into result
+func (e *engine) get(ctx context.Context, reqURL string, result interface{}) error {
+	return e.do(ctx, http.MethodGet, reqURL, nil, result)
+}
+
+// Send a request, with body encoded as JSON unless it is nil, and decode the
+// JSON response into result unless it is nil
+// Any 2xx status is a success, as are the statuses in ok; 204 No Content and
+// empty bodies leave result untouched. Other statuses are decoded into an *Error
+// A request rejected with 401 is retried once with a fresh token
+// The request, with any retries, gets a span of its own
+func (e *engine) do(ctx context.Context, method, reqURL string, body, result interface{}, ok ...int) (err error) {
+	ctx, span := e.startRequest(ctx, method)
+	var res *http.Response
+	defer func() {
+		span.end(method, reqURL, res, err)
+	}()
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	res, err = e.send(ctx, method, reqURL, payload)
+	if err == nil && res.StatusCode == http.StatusUnauthorized && e.token != nil {
+		res.Body.Close()
+		countRetry(ctx)
+		if e.metrics != nil {
+			e.metrics.ObserveRetry(method, endpointOf(res.Request), http.StatusUnauthorized)
+		}
+		res, err = e.send(ctx, method, reqURL, payload)
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if (res.StatusCode < 200 || res.StatusCode >= 300) && isFailure(res.StatusCode, ok) {
+		return decodeError(res)
+	}
+	if result == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(result)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// Send one attempt at a request
+// On 401 the token used is invalidated so a retry gets a new one
+func (e *engine) send(ctx context.Context, method, reqURL string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.acceptLanguage != "" {
+		req.Header.Set("Accept-Language", e.acceptLanguage)
+	}
+
+	token := ""
+	if e.token != nil {
+		if token, err = e.token(ctx); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := e.http.Do(req)
+	if err == nil && res.StatusCode == http.StatusUnauthorized && e.invalidate != nil {
+		e.invalidate(token)
+	}
+	return res, err
+}
+
+// Source: https://github.com/zmb3/spotify/
+
+// isFailure determines whether the code indicates failure
+func isFailure(code int, validCodes []int) bool {
+	for _, item := range validCodes {
+		if item == code {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package spotigo_test
+
+import (
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+)
+
+func TestUserRefreshesExpiredToken(t *testing.T) {
+	srv := newServer(t)
+	u := newUser(t, srv, spotigo.WithScopes(spotigo.ScopeUserReadPrivate))
+
+	srv.ExpireTokens()
+	before := countRequests(srv, "POST /api/token")
+	profile, err := u.GetCurrentProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ID != spotigotest.DefaultUserID {
+		t.Errorf("profile %q, want %q", profile.ID, spotigotest.DefaultUserID)
+	}
+	if n := countRequests(srv, "POST /api/token") - before; n != 1 {
+		t.Errorf("%d token requests after the 401, want 1", n)
+	}
+}
@@ -0,0 +1,53 @@
+package spotigo
+
+// Episode struct- maps to Spotify JSON response format by tag `json: "var_name"`
+// Struct generated by putting Spotify JSON data into JSON to Go struct generator at:
+// https://mholt.github.io/json-to-go/
+type Episode struct {
+	AudioPreviewURL string `json:"audio_preview_url"`
+	Description     string `json:"description"`
+	HTMLDescription string `json:"html_description"`
+	DurationMs      int    `json:"duration_ms"`
+	Explicit        bool   `json:"explicit"`
+	ExternalUrls    struct {
+		Spotify string `json:"spotify"`
+	} `json:"external_urls"`
+	Href   string `json:"href"`
+	ID     string `json:"id"`
+	Images []struct {
+		Height int    `json:"height"`
+		URL    string `json:"url"`
+		Width  int    `json:"width"`
+	} `json:"images"`
+	IsExternallyHosted   bool     `json:"is_externally_hosted"`
+	IsPlayable           bool     `json:"is_playable"`
+	Languages            []string `json:"languages"`
+	Name                 string   `json:"name"`
+	ReleaseDate          string   `json:"release_date"`
+	ReleaseDatePrecision string   `json:"release_date_precision"`
+	// Only set when the Episode is fetched on its own, not in search results
+	Show struct {
+		Href      string `json:"href"`
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		Publisher string `json:"publisher"`
+		URI       string `json:"uri"`
+	} `json:"show"`
+	Type string `json:"type"`
+	URI  string `json:"uri"`
+}
+
+// Get Episode Name
+func (e *Episode) GetName() string {
+	return e.Name
+}
+
+// Get Episode URI
+func (e *Episode) GetURI() string {
+	return uriOf(EntityEpisode, e.ID)
+}
+
+// Get Episode Duration in milliseconds
+func (e *Episode) GetDurationMs() int {
+	return e.DurationMs
+}
@@ -0,0 +1,212 @@
+package spotigo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors- compare with errors.Is
+// API errors (*Error) match the sentinel for their HTTP status or player reason
+var (
+	// The request or an argument was malformed (HTTP 400, or rejected before sending)
+	ErrInvalidInput = errors.New("spotify: invalid input")
+	// The access token is missing, invalid or expired (HTTP 401)
+	ErrUnauthorized = errors.New("spotify: unauthorized")
+	// The request was refused (HTTP 403)
+	ErrForbidden = errors.New("spotify: forbidden")
+	// The requested item does not exist, or a search had no results (HTTP 404)
+	ErrNotFound = errors.New("spotify: not found")
+	// Too many requests were made (HTTP 429)
+	ErrRateLimited = errors.New("spotify: rate limited")
+	// A player command was sent while the User has no active device
+	ErrNoActiveDevice = errors.New("spotify: no active device")
+	// A player command was sent for a User without Spotify Premium
+	ErrPremiumRequired = errors.New("spotify: premium required")
+	// A User login wasn't completed in the browser before the auth timeout
+	ErrLoginTimeout = errors.New("spotify: login timed out")
+	// A User method was called without a scope it needs; see MissingScopeError
+	ErrMissingScope = errors.New("spotify: missing scope")
+	// No search result matched a name closely enough in strict mode
+	ErrLowConfidence = errors.New("spotify: low confidence match")
+)
+
+// Player error reasons that map to their own sentinel errors
+const (
+	reasonNoActiveDevice  = "NO_ACTIVE_DEVICE"
+	reasonPremiumRequired = "PREMIUM_REQUIRED"
+)
+
+// Source: https://github.com/zmb3/spotify/
+
+// Error represents an error returned by the Spotify Web API.
+type Error struct {
+	// A short description of the error.
+	Message string `json:"message"`
+	// The HTTP status code.
+	Status int `json:"status"`
+	// Reason is set on player errors, e.g. "NO_ACTIVE_DEVICE" or "PREMIUM_REQUIRED".
+	Reason string `json:"reason"`
+	// RetryAfter is how long a 429 response asked to wait before retrying.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// return error message
+func (e *Error) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("spotify: %s (HTTP %d, %s)", e.Message, e.Status, e.Reason)
+	}
+	return fmt.Sprintf("spotify: %s (HTTP %d)", e.Message, e.Status)
+}
+
+// Is reports whether the error matches one of the sentinel errors
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNoActiveDevice:
+		return e.Reason == reasonNoActiveDevice
+	case ErrPremiumRequired:
+		return e.Reason == reasonPremiumRequired
+	case ErrInvalidInput:
+		return e.Status == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Status == http.StatusForbidden && e.Reason != reasonPremiumRequired
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound && e.Reason != reasonNoActiveDevice
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// decodeError decodes an Error from an HTTP response.
+func decodeError(resp *http.Response) error {
+	err := decodeErrorBody(resp)
+	if e, ok := err.(*Error); ok && resp.StatusCode == http.StatusTooManyRequests {
+		e.RetryAfter, _ = retryAfter(resp)
+	}
+	return err
+}
+
+// Decode an Error from an HTTP response's body
+func decodeErrorBody(resp *http.Response) error {
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if len(responseBody) == 0 {
+		return &Error{
+			Message: fmt.Sprintf("%s (body empty)", http.StatusText(resp.StatusCode)),
+			Status:  resp.StatusCode,
+		}
+	}
+
+	var e struct {
+		E    json.RawMessage `json:"error"`
+		Desc string          `json:"error_description"`
+	}
+	apiErr := Error{}
+	err = json.Unmarshal(responseBody, &e)
+	if err == nil && json.Unmarshal(e.E, &apiErr) != nil {
+		// Accounts Service errors are a code with an optional description
+		var code string
+		err = json.Unmarshal(e.E, &code)
+		apiErr.Message = code
+		if e.Desc != "" {
+			apiErr.Message = code + ": " + e.Desc
+		}
+	}
+	if err != nil {
+		return &Error{
+			Message: fmt.Sprintf("couldn't decode error: (%d) [%s]", len(responseBody), responseBody),
+			Status:  resp.StatusCode,
+		}
+	}
+
+	// The status in the body is informational; the HTTP status is authoritative
+	apiErr.Status = resp.StatusCode
+	if apiErr.Message == "" {
+		// Some errors will result in there being a useful status-code but an
+		// empty message, which will confuse the user (who only has access to
+		// the message and not the code). An example of this is when we send
+		// some of the arguments directly in the HTTP query and the URL ends-up
+		// being too long.
+
+		apiErr.Message = fmt.Sprintf("unexpected HTTP %d: %s (empty error)",
+			resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	return &apiErr
+}
+
+// MissingScopeError reports a User method that was called without the scopes
+// it needs; it is returned before any request is made
+// It matches ErrMissingScope with errors.Is
+type MissingScopeError struct {
+	// The method that was called, e.g. "GetSavedTracks"
+	Method string
+	// The scopes it needs that the user hasn't granted
+	Scopes []string
+}
+
+// return error message
+func (e *MissingScopeError) Error() string {
+	return fmt.Sprintf("spotify: %s needs scope %s, which the user hasn't granted", e.Method, strings.Join(e.Scopes, ", "))
+}
+
+// Is reports whether target is ErrMissingScope
+func (e *MissingScopeError) Is(target error) bool {
+	return target == ErrMissingScope
+}
+
+// BatchError reports the failures of a call that handles several items
+// Errs is aligned with the call's input; a nil entry means that item succeeded
+type BatchError struct {
+	Errs []error
+}
+
+// Summarize the failed items
+func (e *BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errs {
+		if err != nil {
+			failed++
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return fmt.Sprintf("spotify: %d of %d items failed, first: %v", failed, len(e.Errs), first)
+}
+
+// Is reports whether any item's error matches target
+func (e *BatchError) Is(target error) bool {
+	for _, err := range e.Errs {
+		if err != nil && errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return a *BatchError if any of errs is non-nil, otherwise nil
+func batchError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return &BatchError{Errs: errs}
+		}
+	}
+	return nil
+}
+
+// Wrap ErrInvalidInput with a description of the bad input
+func invalidInput(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidInput, fmt.Sprintf(format, a...))
+}
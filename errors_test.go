@@ -0,0 +1,160 @@
+package spotigo_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+)
+
+var sentinels = map[string]error{
+	"ErrInvalidInput":    spotigo.ErrInvalidInput,
+	"ErrUnauthorized":    spotigo.ErrUnauthorized,
+	"ErrForbidden":       spotigo.ErrForbidden,
+	"ErrNotFound":        spotigo.ErrNotFound,
+	"ErrRateLimited":     spotigo.ErrRateLimited,
+	"ErrNoActiveDevice":  spotigo.ErrNoActiveDevice,
+	"ErrPremiumRequired": spotigo.ErrPremiumRequired,
+}
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		// Whether the Accounts Service fails, rather than the Web API
+		accounts   bool
+		status     int
+		retryAfter string
+		body       string
+
+		// The sentinel the error matches, if any; it matches no others
+		want       string
+		message    string
+		reason     string
+		waitBefore time.Duration
+	}{
+		{
+			name: "bad request", status: 400,
+			body: `{"error":{"status":400,"message":"invalid id"}}`,
+			want: "ErrInvalidInput", message: "invalid id",
+		},
+		{
+			name: "expired token", status: 401,
+			body: `{"error":{"status":401,"message":"The access token expired"}}`,
+			want: "ErrUnauthorized", message: "The access token expired",
+		},
+		{
+			name: "forbidden", status: 403,
+			body: `{"error":{"status":403,"message":"Forbidden"}}`,
+			want: "ErrForbidden", message: "Forbidden",
+		},
+		{
+			name: "premium required", status: 403,
+			body: `{"error":{"status":403,"message":"Player command failed: Premium required","reason":"PREMIUM_REQUIRED"}}`,
+			want: "ErrPremiumRequired", reason: "PREMIUM_REQUIRED",
+		},
+		{
+			name: "not found", status: 404,
+			body: `{"error":{"status":404,"message":"Non existing id"}}`,
+			want: "ErrNotFound", message: "Non existing id",
+		},
+		{
+			name: "no active device", status: 404,
+			body: `{"error":{"status":404,"message":"Player command failed: No active device found","reason":"NO_ACTIVE_DEVICE"}}`,
+			want: "ErrNoActiveDevice", reason: "NO_ACTIVE_DEVICE",
+		},
+		{
+			name: "rate limited", status: 429, retryAfter: "3600",
+			body: `{"error":{"status":429,"message":"API rate limit exceeded"}}`,
+			want: "ErrRateLimited", waitBefore: time.Hour,
+		},
+		{
+			name: "body status is ignored", status: 404,
+			body: `{"error":{"status":500,"message":"gone"}}`,
+			want: "ErrNotFound", message: "gone",
+		},
+		{
+			name: "empty body", status: 502,
+			message: "Bad Gateway (body empty)",
+		},
+		{
+			name: "not JSON", status: 503,
+			body:    "<html>down</html>",
+			message: "couldn't decode error",
+		},
+		{
+			name: "empty message", status: 414,
+			body:    `{"error":{"status":414}}`,
+			message: "unexpected HTTP 414",
+		},
+		{
+			name: "accounts bad client", accounts: true, status: 400,
+			body: `{"error":"invalid_client","error_description":"Invalid client secret"}`,
+			want: "ErrInvalidInput", message: "invalid_client: Invalid client secret",
+		},
+		{
+			name: "accounts without description", accounts: true, status: 401,
+			body: `{"error":"invalid_client"}`,
+			want: "ErrUnauthorized", message: "invalid_client",
+		},
+		{
+			name: "accounts rate limited", accounts: true, status: 429, retryAfter: "7200",
+			body: `{"error":"too_many_requests"}`,
+			want: "ErrRateLimited", waitBefore: 2 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		fail := func(w http.ResponseWriter) {
+			if tt.retryAfter != "" {
+				w.Header().Set("Retry-After", tt.retryAfter)
+			}
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/token" && !tt.accounts {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token":"tok","token_type":"Bearer","expires_in":3600}`))
+				return
+			}
+			fail(w)
+		}))
+
+		limiter := spotigo.NewRateLimiter(spotigo.RequestRate(0, 0), spotigo.RetryBackoff(time.Millisecond, 10*time.Millisecond))
+		q, err := spotigo.NewQuery("id", "secret",
+			spotigo.WithAPIURL(ts.URL+"/v1/"), spotigo.WithAccountsURL(ts.URL+"/"), spotigo.WithRateLimiter(limiter))
+		if !tt.accounts {
+			if err != nil {
+				t.Fatalf("%s: NewQuery: %v", tt.name, err)
+			}
+			_, err = q.GetTrackByURI("4uLU6hMCjMI75M1A2tKUQC")
+		}
+		ts.Close()
+
+		var apiErr *spotigo.Error
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: got %v, want an *Error", tt.name, err)
+			continue
+		}
+		if apiErr.Status != tt.status {
+			t.Errorf("%s: Status %d, want %d", tt.name, apiErr.Status, tt.status)
+		}
+		if !strings.Contains(apiErr.Message, tt.message) {
+			t.Errorf("%s: Message %q, want it to contain %q", tt.name, apiErr.Message, tt.message)
+		}
+		if apiErr.Reason != tt.reason {
+			t.Errorf("%s: Reason %q, want %q", tt.name, apiErr.Reason, tt.reason)
+		}
+		if apiErr.RetryAfter != tt.waitBefore {
+			t.Errorf("%s: RetryAfter %v, want %v", tt.name, apiErr.RetryAfter, tt.waitBefore)
+		}
+		for name, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (name == tt.want) {
+				t.Errorf("%s: errors.Is(err, %s) = %v", tt.name, name, got)
+			}
+		}
+	}
+}
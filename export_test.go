@@ -0,0 +1,10 @@
+package spotigo
+
+import "testing"
+
+// Make logins open URLs with open instead of a browser until the test ends
+func SetOpenBrowser(t testing.TB, open func(url string) error) {
+	old := openBrowser
+	openBrowser = open
+	t.Cleanup(func() { openBrowser = old })
+}
@@ -0,0 +1,260 @@
+package spotigo
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Lookups by the industry codes distributors use: ISRCs for recordings and
+// UPCs for releases
+// One code can match several Spotify items, e.g. a track on both a single and
+// its album, or an album released separately in different markets; these are
+// kept as editions of the code and the most popular one is returned first
+// Codes are searched without a market, so editions from every market are
+// returned, including ones that aren't playable in the User's; check an
+// edition's AvailableMarkets to pick one for a market
+
+// ISRCResult struct- the Tracks found for a list of ISRCs
+type ISRCResult struct {
+	// The most popular Track for each ISRC that matched, keyed by the ISRC as given
+	Tracks map[string]Track
+	// Every distinct Track with each ISRC, most popular first
+	Editions map[string][]Track
+	// ISRCs that no Track matched, including malformed ones, in input order
+	Unmatched []string
+}
+
+// Number of codes matched, or 0 for a nil result
+func (r *ISRCResult) count() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.Tracks)
+}
+
+// UPCResult struct- the Albums found for a list of UPCs
+type UPCResult struct {
+	// The most popular Album for each UPC that matched, keyed by the UPC as given
+	Albums map[string]Album
+	// Every distinct Album with each UPC, most popular first
+	Editions map[string][]Album
+	// UPCs that no Album matched, including malformed ones, in input order
+	Unmatched []string
+}
+
+// Number of codes matched, or 0 for a nil result
+func (r *UPCResult) count() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.Albums)
+}
+
+// An ISRC: country, registrant, year and designation code, e.g. USAT21234567
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+
+// A UPC-A or EAN-13 barcode
+var upcPattern = regexp.MustCompile(`^[0-9]{12,13}$`)
+
+// Normalize a code as distributors write it, e.g. "US-AT2-12-34567"
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code))
+}
+
+// Look up each distinct code once with search, which returns how many items
+// it found, and pass every code given that found some to matched along with
+// its normalized form
+// Codes are searched several at once with parallel, so search must be safe
+// for concurrent use
+// Returns the codes that are malformed or found nothing, in order, and a
+// *BatchError aligned with codes if any search failed
+func lookupCodes(codes []string, pattern *regexp.Regexp, parallel func(n int, do func(i int)), search func(code string) (int, error), matched func(given, code string)) ([]string, error) {
+	type lookup struct {
+		n   int
+		err error
+	}
+	distinct := make([]string, 0, len(codes))
+	index := make(map[string]int)
+	for _, given := range codes {
+		code := normalizeCode(given)
+		if _, ok := index[code]; !ok && pattern.MatchString(code) {
+			index[code] = len(distinct)
+			distinct = append(distinct, code)
+		}
+	}
+	lookups := make([]lookup, len(distinct))
+	parallel(len(distinct), func(i int) {
+		lookups[i].n, lookups[i].err = search(distinct[i])
+	})
+
+	unmatched := make([]string, 0)
+	reported := make(map[string]bool)
+	errs := make([]error, len(codes))
+	for i, given := range codes {
+		code := normalizeCode(given)
+		var l lookup
+		if j, ok := index[code]; ok {
+			l = lookups[j]
+		}
+
+		switch {
+		case l.err != nil:
+			errs[i] = l.err
+		case l.n > 0:
+			matched(given, code)
+		case !reported[given]:
+			reported[given] = true
+			unmatched = append(unmatched, given)
+		}
+	}
+	return unmatched, batchError(errs)
+}
+
+// Get the Tracks with each of the given ISRCs
+// Codes may contain hyphens and spaces; each distinct code is searched once,
+// several at once (see WithParallelism)
+// On failure the error is a *BatchError aligned with isrcs, and the codes
+// that failed are neither in the result nor in Unmatched
+func (q Query) GetTracksByISRC(isrcs ...string) (*ISRCResult, error) {
+	return q.GetTracksByISRCContext(context.Background(), isrcs...)
+}
+
+// GetTracksByISRCContext is GetTracksByISRC with a context
+func (q Query) GetTracksByISRCContext(ctx context.Context, isrcs ...string) (res *ISRCResult, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetTracksByISRC")
+	defer func() { span.endItems(err, res.count()) }()
+
+	result := &ISRCResult{Tracks: make(map[string]Track), Editions: make(map[string][]Track)}
+	found := make(map[string][]Track)
+	var mu sync.Mutex
+
+	search := func(isrc string) (int, error) {
+		tracks, err := q.tracksWithISRC(ctx, isrc)
+		mu.Lock()
+		found[isrc] = tracks
+		mu.Unlock()
+		return len(tracks), err
+	}
+	matched := func(given, isrc string) {
+		result.Tracks[given] = found[isrc][0]
+		result.Editions[given] = found[isrc]
+	}
+
+	result.Unmatched, err = lookupCodes(isrcs, isrcPattern, q.parallel, search, matched)
+	return result, err
+}
+
+// Search for the distinct Tracks with isrc, most popular first
+func (q Query) tracksWithISRC(ctx context.Context, isrc string) ([]Track, error) {
+	res, err := q.searchPage(ctx, SearchQuery{ISRC: isrc}.String(), SearchTypeTrack, searchParams{limit: maxSearchLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]Track, 0)
+	ids := make(map[string]bool)
+	for _, t := range res.Tracks.Items {
+		// Search can be lenient, so only keep exact matches
+		if normalizeCode(t.ExternalIds.Isrc) != isrc || ids[t.ID] {
+			continue
+		}
+		ids[t.ID] = true
+		tracks = append(tracks, t)
+	}
+	sort.SliceStable(tracks, func(i, j int) bool { return tracks[i].Popularity > tracks[j].Popularity })
+	return tracks, nil
+}
+
+// Get the Albums with each of the given UPCs
+// Codes may contain hyphens and spaces; each distinct code is searched once,
+// several at once (see WithParallelism)
+// On failure the error is a *BatchError aligned with upcs, and the codes
+// that failed are neither in the result nor in Unmatched
+func (q Query) GetAlbumsByUPC(upcs ...string) (*UPCResult, error) {
+	return q.GetAlbumsByUPCContext(context.Background(), upcs...)
+}
+
+// GetAlbumsByUPCContext is GetAlbumsByUPC with a context
+func (q Query) GetAlbumsByUPCContext(ctx context.Context, upcs ...string) (res *UPCResult, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetAlbumsByUPC")
+	defer func() { span.endItems(err, res.count()) }()
+
+	result := &UPCResult{Albums: make(map[string]Album), Editions: make(map[string][]Album)}
+	found := make(map[string][]Album)
+	var mu sync.Mutex
+
+	search := func(upc string) (int, error) {
+		albums, err := q.albumsWithUPC(ctx, upc)
+		mu.Lock()
+		found[upc] = albums
+		mu.Unlock()
+		return len(albums), err
+	}
+	matched := func(given, upc string) {
+		result.Albums[given] = found[upc][0]
+		result.Editions[given] = found[upc]
+	}
+
+	result.Unmatched, err = lookupCodes(upcs, upcPattern, q.parallel, search, matched)
+	return result, err
+}
+
+// Search for the distinct Albums with upc, most popular first
+// Albums in search results lack their UPC and popularity, so the full Albums
+// are fetched to check them. Editions that can't be fetched, e.g. because
+// they are missing or region-locked, are left out; the lookup only fails if
+// none could be fetched and not all of them are missing
+func (q Query) albumsWithUPC(ctx context.Context, upc string) ([]Album, error) {
+	res, err := q.searchPage(ctx, SearchQuery{UPC: upc}.String(), SearchTypeAlbum, searchParams{limit: maxSearchLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, a := range res.Albums.Items {
+		if !seen[a.ID] {
+			seen[a.ID] = true
+			ids = append(ids, a.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	full, err := q.GetAlbumsByURIsContext(ctx, ids...)
+	var batch *BatchError
+	if err != nil && !errors.As(err, &batch) {
+		return nil, err
+	}
+	albums := make([]Album, 0)
+	var failed error
+	for i, a := range full {
+		if batch != nil && batch.Errs[i] != nil {
+			if !errors.Is(batch.Errs[i], ErrNotFound) && failed == nil {
+				failed = batch.Errs[i]
+			}
+			continue
+		}
+		// UPCs are 12 digits, but are often written as 13-digit EANs with a
+		// leading 0
+		if strings.TrimLeft(normalizeCode(a.ExternalIds.Upc), "0") != strings.TrimLeft(upc, "0") {
+			continue
+		}
+		albums = append(albums, a)
+	}
+	if len(albums) == 0 && failed != nil {
+		return nil, failed
+	}
+	sort.SliceStable(albums, func(i, j int) bool { return albums[i].Popularity > albums[j].Popularity })
+	return albums, nil
+}
@@ -0,0 +1,133 @@
+package spotigo_test
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+)
+
+// A Track with an ISRC
+func isrcTrack(id, isrc string, popularity int) spotigo.Track {
+	t := newTrack(id, "Song "+id, "Remi Wolf", popularity)
+	t.ExternalIds.Isrc = isrc
+	return t
+}
+
+// An Album with a UPC
+func upcAlbum(id, upc string, popularity int) spotigo.Album {
+	a := spotigo.Album{ID: id, Name: "Album " + id, Popularity: popularity}
+	a.ExternalIds.Upc = upc
+	return a
+}
+
+// IDs of tracks, in order
+func trackIDs(tracks []spotigo.Track) []string {
+	ids := make([]string, len(tracks))
+	for i, t := range tracks {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func TestGetTracksByISRC(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(
+		isrcTrack("single", "USAT21234567", 40),
+		isrcTrack("album", "USAT21234567", 80),
+		isrcTrack("other", "GBAYE0601498", 10),
+		isrcTrack("near", "USAT21234568", 90),
+	)
+	q := newQuery(t, srv)
+
+	given := []string{"us-at2-12-34567", "USAT21234567", "GB AYE 06 01498", "bad", "USAT29999999", "bad"}
+	res, err := q.GetTracksByISRC(given...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for code, want := range map[string][]string{
+		"us-at2-12-34567": {"album", "single"},
+		"USAT21234567":    {"album", "single"},
+		"GB AYE 06 01498": {"other"},
+	} {
+		if got := res.Tracks[code].ID; got != want[0] {
+			t.Errorf("Tracks[%q] = %q, want %q", code, got, want[0])
+		}
+		if got := trackIDs(res.Editions[code]); !reflect.DeepEqual(got, want) {
+			t.Errorf("Editions[%q] = %v, want %v", code, got, want)
+		}
+	}
+	if len(res.Tracks) != 3 {
+		t.Errorf("%d Tracks, want 3", len(res.Tracks))
+	}
+	if want := []string{"bad", "USAT29999999"}; !reflect.DeepEqual(res.Unmatched, want) {
+		t.Errorf("Unmatched = %v, want %v", res.Unmatched, want)
+	}
+	// Each distinct well-formed code is searched once
+	if n := countRequests(srv, "GET /v1/search"); n != 3 {
+		t.Errorf("%d searches, want 3", n)
+	}
+}
+
+func TestGetAlbumsByUPC(t *testing.T) {
+	srv := newServer(t)
+	srv.AddAlbum(
+		upcAlbum("a1", "0602537251245", 30),
+		upcAlbum("a2", "602537251245", 60),
+		upcAlbum("b1", "886443927087", 10),
+	)
+	q := newQuery(t, srv)
+
+	// UPC-A and EAN-13 forms of a code match each other
+	res, err := q.GetAlbumsByUPC("602537251245", "0886443927087", "000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Albums["602537251245"].ID; got != "a2" {
+		t.Errorf("Albums[602537251245] = %q, want a2", got)
+	}
+	if got := len(res.Editions["602537251245"]); got != 2 {
+		t.Errorf("%d editions of 602537251245, want 2", got)
+	}
+	if got := res.Albums["0886443927087"].ID; got != "b1" {
+		t.Errorf("Albums[0886443927087] = %q, want b1", got)
+	}
+	if want := []string{"000000000000"}; !reflect.DeepEqual(res.Unmatched, want) {
+		t.Errorf("Unmatched = %v, want %v", res.Unmatched, want)
+	}
+}
+
+func TestGetTracksByISRCBatchError(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(isrcTrack("t1", "USAT21234567", 40), isrcTrack("t2", "GBAYE0601498", 40))
+	q := newQuery(t, srv, spotigo.WithParallelism(1))
+
+	// The first code's search fails, and so does every spelling of it
+	srv.FailNext(1, http.StatusBadRequest, 0)
+	res, err := q.GetTracksByISRC("GBAYE0601498", "USAT21234567", "bad", "gb-aye-06-01498")
+	var batch *spotigo.BatchError
+	if !errors.As(err, &batch) {
+		t.Fatalf("got %v, want a *BatchError", err)
+	}
+	if len(batch.Errs) != 4 {
+		t.Fatalf("%d errors, want one per code", len(batch.Errs))
+	}
+	for i, failed := range []bool{true, false, false, true} {
+		if (batch.Errs[i] != nil) != failed {
+			t.Errorf("Errs[%d] = %v", i, batch.Errs[i])
+		}
+	}
+	if !errors.Is(batch.Errs[0], spotigo.ErrInvalidInput) {
+		t.Errorf("Errs[0] = %v", batch.Errs[0])
+	}
+
+	// Failed codes are neither found nor unmatched
+	if res.Tracks["USAT21234567"].ID != "t1" || len(res.Tracks) != 1 {
+		t.Errorf("Tracks = %v", res.Tracks)
+	}
+	if want := []string{"bad"}; !reflect.DeepEqual(res.Unmatched, want) {
+		t.Errorf("Unmatched = %v, want %v", res.Unmatched, want)
+	}
+}
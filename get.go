@@ -1,27 +1,38 @@
 package spotigo
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
 	"time"
 )
 
 // Get a User's Saved Tracks
 // limit sets the number of tracks to return
 // If getAll is true, limit is disregarded
-func (u *User) GetSavedTracks(getAll bool, limit int) ([]Track, bool) {
+func (u *User) GetSavedTracks(getAll bool, limit int) ([]Track, error) {
+	return u.GetSavedTracksContext(context.Background(), getAll, limit)
+}
+
+// GetSavedTracksContext is GetSavedTracks with a context
+func (u *User) GetSavedTracksContext(ctx context.Context, getAll bool, limit int) (items []Track, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetSavedTracks")
+	defer func() { span.endItems(err, len(items)) }()
+
+	if err := u.checkScopes("GetSavedTracks"); err != nil {
+		return nil, err
+	}
 	const MAX_LIMIT = 50
 	tracks := make([]Track, 0)
-	ok := true
 	offset := 0
 
 	if limit < 0 {
-		return nil, false
+		return nil, invalidInput("negative limit %d", limit)
 	}
 	if getAll {
-		numTracks, ok2 := u.GetNumSavedTracks()
-		ok = ok && ok2
+		numTracks, err := u.GetNumSavedTracksContext(ctx)
+		if err != nil {
+			return nil, err
+		}
 		limit = numTracks
 	}
 
@@ -32,7 +43,9 @@ func (u *User) GetSavedTracks(getAll bool, limit int) ([]Track, bool) {
 		reqURL := u.baseURL + "me/tracks?limit=" + fmt.Sprint(MAX_LIMIT) + "&offset=" + fmt.Sprint(offset)
 		savedTracks := savedTracks{}
 
-		ok = u.sendGetRequest(reqURL, &savedTracks) && ok
+		if err := u.sendGetRequest(ctx, reqURL, &savedTracks); err != nil {
+			return nil, err
+		}
 
 		for _, x := range savedTracks.Items {
 			tracks = append(tracks, x.Track)
@@ -43,26 +56,44 @@ func (u *User) GetSavedTracks(getAll bool, limit int) ([]Track, bool) {
 
 	}
 
+	// Spotify rejects requests for 0 items
+	if limit == 0 {
+		return tracks, nil
+	}
+
 	// Format request URL
 	reqURL := u.baseURL + "me/tracks?limit=" + fmt.Sprint(limit) + "&offset=" + fmt.Sprint(offset)
 	savedTracks := savedTracks{}
 
 	// Send request, store results in savedTracks
-	ok = u.sendGetRequest(reqURL, &savedTracks) && ok
+	if err := u.sendGetRequest(ctx, reqURL, &savedTracks); err != nil {
+		return nil, err
+	}
 
 	for _, x := range savedTracks.Items {
 		tracks = append(tracks, x.Track)
 	}
 
-	return tracks, ok
+	return tracks, nil
 }
 
 // Get the number of tracks a User has saved
-func (u *User) GetNumSavedTracks() (int, bool) {
+func (u *User) GetNumSavedTracks() (int, error) {
+	return u.GetNumSavedTracksContext(context.Background())
+}
+
+// GetNumSavedTracksContext is GetNumSavedTracks with a context
+func (u *User) GetNumSavedTracksContext(ctx context.Context) (_ int, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetNumSavedTracks")
+	defer span.end(&err)
+
+	if err := u.checkScopes("GetNumSavedTracks"); err != nil {
+		return 0, err
+	}
 	reqURL := u.baseURL + "me/tracks?limit=1"
 	savedTracks := savedTracks{}
-	ok := u.sendGetRequest(reqURL, &savedTracks)
-	return savedTracks.Total, ok
+	err = u.sendGetRequest(ctx, reqURL, &savedTracks)
+	return savedTracks.Total, err
 }
 
 // Saved Tracks struct- maps to Spotify JSON response format by tag `json: "var_name"`
@@ -84,18 +115,30 @@ type savedTracks struct {
 // Get a User's Saved Albums
 // limit sets the number of albums to return
 // If getAll is true, limit is disregarded
-func (u *User) GetSavedAlbums(getAll bool, limit int) ([]Album, bool) {
+func (u *User) GetSavedAlbums(getAll bool, limit int) ([]Album, error) {
+	return u.GetSavedAlbumsContext(context.Background(), getAll, limit)
+}
+
+// GetSavedAlbumsContext is GetSavedAlbums with a context
+func (u *User) GetSavedAlbumsContext(ctx context.Context, getAll bool, limit int) (items []Album, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetSavedAlbums")
+	defer func() { span.endItems(err, len(items)) }()
+
+	if err := u.checkScopes("GetSavedAlbums"); err != nil {
+		return nil, err
+	}
 	const MAX_LIMIT = 50
 	albums := make([]Album, 0)
-	ok := true
 	offset := 0
 
 	if limit < 0 {
-		return nil, false
+		return nil, invalidInput("negative limit %d", limit)
 	}
 	if getAll {
-		numAlbums, ok2 := u.GetNumSavedAlbums()
-		ok = ok && ok2
+		numAlbums, err := u.GetNumSavedAlbumsContext(ctx)
+		if err != nil {
+			return nil, err
+		}
 		limit = numAlbums
 	}
 
@@ -106,7 +149,9 @@ func (u *User) GetSavedAlbums(getAll bool, limit int) ([]Album, bool) {
 		reqURL := u.baseURL + "me/albums?limit=" + fmt.Sprint(MAX_LIMIT) + "&offset=" + fmt.Sprint(offset)
 		savedAlbums := savedAlbums{}
 
-		ok = u.sendGetRequest(reqURL, &savedAlbums) && ok
+		if err := u.sendGetRequest(ctx, reqURL, &savedAlbums); err != nil {
+			return nil, err
+		}
 
 		for _, x := range savedAlbums.Items {
 			albums = append(albums, x.Album)
@@ -117,22 +162,40 @@ func (u *User) GetSavedAlbums(getAll bool, limit int) ([]Album, bool) {
 
 	}
 
+	// Spotify rejects requests for 0 items
+	if limit == 0 {
+		return albums, nil
+	}
+
 	reqURL := u.baseURL + "me/albums?limit=" + fmt.Sprint(limit) + "&offset=" + fmt.Sprint(offset)
 	savedAlbums := savedAlbums{}
 
-	ok = u.sendGetRequest(reqURL, &savedAlbums) && ok
+	if err := u.sendGetRequest(ctx, reqURL, &savedAlbums); err != nil {
+		return nil, err
+	}
 
 	for _, x := range savedAlbums.Items {
 		albums = append(albums, x.Album)
 	}
 
-	return albums, ok
+	return albums, nil
 }
-func (u *User) GetNumSavedAlbums() (int, bool) {
+func (u *User) GetNumSavedAlbums() (int, error) {
+	return u.GetNumSavedAlbumsContext(context.Background())
+}
+
+// GetNumSavedAlbumsContext is GetNumSavedAlbums with a context
+func (u *User) GetNumSavedAlbumsContext(ctx context.Context) (_ int, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetNumSavedAlbums")
+	defer span.end(&err)
+
+	if err := u.checkScopes("GetNumSavedAlbums"); err != nil {
+		return 0, err
+	}
 	reqURL := u.baseURL + "me/albums?limit=1"
 	savedAlbums := savedAlbums{}
-	ok := u.sendGetRequest(reqURL, &savedAlbums)
-	return savedAlbums.Total, ok
+	err = u.sendGetRequest(ctx, reqURL, &savedAlbums)
+	return savedAlbums.Total, err
 }
 
 // Saved Albums struct- maps to Spotify JSON response format by tag `json: "var_name"`
@@ -154,18 +217,30 @@ type savedAlbums struct {
 // Get a User's Saved Playlists
 // limit sets the number of playlists to return
 // If getAll is true, limit is disregarded
-func (u *User) GetSavedPlaylists(getAll bool, limit int) ([]Playlist, bool) {
+func (u *User) GetSavedPlaylists(getAll bool, limit int) ([]Playlist, error) {
+	return u.GetSavedPlaylistsContext(context.Background(), getAll, limit)
+}
+
+// GetSavedPlaylistsContext is GetSavedPlaylists with a context
+func (u *User) GetSavedPlaylistsContext(ctx context.Context, getAll bool, limit int) (items []Playlist, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetSavedPlaylists")
+	defer func() { span.endItems(err, len(items)) }()
+
+	if err := u.checkScopes("GetSavedPlaylists"); err != nil {
+		return nil, err
+	}
 	const MAX_LIMIT = 50
 	playlists := make([]Playlist, 0)
-	ok := true
 	offset := 0
 
 	if limit < 0 {
-		return nil, false
+		return nil, invalidInput("negative limit %d", limit)
 	}
 	if getAll {
-		numPlaylists, ok2 := u.GetNumSavedPlaylists()
-		ok = ok && ok2
+		numPlaylists, err := u.GetNumSavedPlaylistsContext(ctx)
+		if err != nil {
+			return nil, err
+		}
 		limit = numPlaylists
 	}
 
@@ -176,7 +251,9 @@ func (u *User) GetSavedPlaylists(getAll bool, limit int) ([]Playlist, bool) {
 		reqURL := u.baseURL + "me/playlists?limit=" + fmt.Sprint(MAX_LIMIT) + "&offset=" + fmt.Sprint(offset)
 		savedPlaylists := savedPlaylists{}
 
-		ok = u.sendGetRequest(reqURL, &savedPlaylists) && ok
+		if err := u.sendGetRequest(ctx, reqURL, &savedPlaylists); err != nil {
+			return nil, err
+		}
 
 		for _, x := range savedPlaylists.Items {
 			playlists = append(playlists, x)
@@ -187,22 +264,40 @@ func (u *User) GetSavedPlaylists(getAll bool, limit int) ([]Playlist, bool) {
 
 	}
 
+	// Spotify rejects requests for 0 items
+	if limit == 0 {
+		return playlists, nil
+	}
+
 	reqURL := u.baseURL + "me/playlists?limit=" + fmt.Sprint(limit) + "&offset=" + fmt.Sprint(offset)
 	savedPlaylists := savedPlaylists{}
 
-	ok = u.sendGetRequest(reqURL, &savedPlaylists) && ok
+	if err := u.sendGetRequest(ctx, reqURL, &savedPlaylists); err != nil {
+		return nil, err
+	}
 
 	for _, x := range savedPlaylists.Items {
 		playlists = append(playlists, x)
 	}
 
-	return playlists, ok
+	return playlists, nil
+}
+func (u *User) GetNumSavedPlaylists() (int, error) {
+	return u.GetNumSavedPlaylistsContext(context.Background())
 }
-func (u *User) GetNumSavedPlaylists() (int, bool) {
+
+// GetNumSavedPlaylistsContext is GetNumSavedPlaylists with a context
+func (u *User) GetNumSavedPlaylistsContext(ctx context.Context) (_ int, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetNumSavedPlaylists")
+	defer span.end(&err)
+
+	if err := u.checkScopes("GetNumSavedPlaylists"); err != nil {
+		return 0, err
+	}
 	reqURL := u.baseURL + "me/playlists?limit=1"
 	savedPlaylists := savedPlaylists{}
-	ok := u.sendGetRequest(reqURL, &savedPlaylists)
-	return savedPlaylists.Total, ok
+	err = u.sendGetRequest(ctx, reqURL, &savedPlaylists)
+	return savedPlaylists.Total, err
 }
 
 // Saved Playlists struct- maps to Spotify JSON response format by tag `json: "var_name"`
@@ -221,18 +316,30 @@ type savedPlaylists struct {
 // Get a User's Saved Artists
 // limit sets the number of artists to return
 // If getAll is true, limit is disregarded
-func (u *User) GetFollowedArtists(getAll bool, limit int) ([]Artist, bool) {
+func (u *User) GetFollowedArtists(getAll bool, limit int) ([]Artist, error) {
+	return u.GetFollowedArtistsContext(context.Background(), getAll, limit)
+}
+
+// GetFollowedArtistsContext is GetFollowedArtists with a context
+func (u *User) GetFollowedArtistsContext(ctx context.Context, getAll bool, limit int) (items []Artist, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetFollowedArtists")
+	defer func() { span.endItems(err, len(items)) }()
+
+	if err := u.checkScopes("GetFollowedArtists"); err != nil {
+		return nil, err
+	}
 	const MAX_LIMIT = 50
 	artists := make([]Artist, 0)
-	ok := true
 	after := ""
 
 	if limit < 0 {
-		return nil, false
+		return nil, invalidInput("negative limit %d", limit)
 	}
 	if getAll {
-		numArtists, ok2 := u.GetNumFollowedArtists()
-		ok = ok && ok2
+		numArtists, err := u.GetNumFollowedArtistsContext(ctx)
+		if err != nil {
+			return nil, err
+		}
 		limit = numArtists
 	}
 
@@ -247,7 +354,9 @@ func (u *User) GetFollowedArtists(getAll bool, limit int) ([]Artist, bool) {
 
 		followedArtists := followedArtists{}
 
-		ok = u.sendGetRequest(reqURL, &followedArtists) && ok
+		if err := u.sendGetRequest(ctx, reqURL, &followedArtists); err != nil {
+			return nil, err
+		}
 
 		for _, x := range followedArtists.Artists.Items {
 			artists = append(artists, x)
@@ -262,6 +371,11 @@ func (u *User) GetFollowedArtists(getAll bool, limit int) ([]Artist, bool) {
 		limit -= MAX_LIMIT
 	}
 
+	// Spotify rejects requests for 0 items
+	if limit == 0 {
+		return artists, nil
+	}
+
 	reqURL := u.baseURL + "me/following?type=artist&limit=" + fmt.Sprint(limit)
 	if after != "" {
 		reqURL += "&after=" + after
@@ -269,20 +383,33 @@ func (u *User) GetFollowedArtists(getAll bool, limit int) ([]Artist, bool) {
 
 	followedArtists := followedArtists{}
 
-	ok = u.sendGetRequest(reqURL, &followedArtists) && ok
+	if err := u.sendGetRequest(ctx, reqURL, &followedArtists); err != nil {
+		return nil, err
+	}
 
 	for _, x := range followedArtists.Artists.Items {
 		artists = append(artists, x)
 	}
 	after = followedArtists.Artists.Cursors.After
 
-	return artists, ok
+	return artists, nil
+}
+func (u *User) GetNumFollowedArtists() (int, error) {
+	return u.GetNumFollowedArtistsContext(context.Background())
 }
-func (u *User) GetNumFollowedArtists() (int, bool) {
+
+// GetNumFollowedArtistsContext is GetNumFollowedArtists with a context
+func (u *User) GetNumFollowedArtistsContext(ctx context.Context) (_ int, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetNumFollowedArtists")
+	defer span.end(&err)
+
+	if err := u.checkScopes("GetNumFollowedArtists"); err != nil {
+		return 0, err
+	}
 	reqURL := u.baseURL + "me/following?type=artist&limit=1"
 	followedArtists := followedArtists{}
-	ok := u.sendGetRequest(reqURL, &followedArtists)
-	return followedArtists.Artists.Total, ok
+	err = u.sendGetRequest(ctx, reqURL, &followedArtists)
+	return followedArtists.Artists.Total, err
 }
 
 // Followed Artists struct- maps to Spotify JSON response format by tag `json: "var_name"`
@@ -303,75 +430,46 @@ type followedArtists struct {
 
 // Get audio features for a track
 // Examples of audio features include Danceability, Energy, Valence
-func (u *User) GetTrackAudioFeatures(q Query, i interface{}) (AudioFeatures, bool) {
-	uri := ""
-	ok := true
-
-	switch v := i.(type) {
-	case string:
-		bytes, searchErr := q.search(v, "track")
-		if searchErr != nil {
-			ok = false
-		}
-		searchResult := searchResult{}
-		jsonErr := json.Unmarshal(bytes, &searchResult)
+func (u *User) GetTrackAudioFeatures(q Query, i interface{}) (AudioFeatures, error) {
+	return u.GetTrackAudioFeaturesContext(context.Background(), q, i)
+}
 
-		if jsonErr != nil {
-			log.Fatal("JSON Error:", jsonErr)
-		}
+// GetTrackAudioFeaturesContext is GetTrackAudioFeatures with a context
+func (u *User) GetTrackAudioFeaturesContext(ctx context.Context, q Query, i interface{}) (_ AudioFeatures, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetTrackAudioFeatures")
+	defer func() { span.endItems(err, 1) }()
 
-		if searchResult.Tracks.Total > 0 {
-			track := searchResult.Tracks.Items[0]
-			uri = track.ID
-		}
-	case Track:
-		uri = v.ID
-	default:
-		fmt.Println("Invalid Type")
-		ok = false
+	audioFeatures := AudioFeatures{}
+	uri, err := q.trackID(ctx, i)
+	if err != nil {
+		return audioFeatures, err
 	}
 	reqURL := u.baseURL + "audio-features/" + uri
 
-	audioFeatures := AudioFeatures{}
-	ok = u.sendGetRequest(reqURL, &audioFeatures) && ok
-	return audioFeatures, ok
+	err = u.sendGetRequest(ctx, reqURL, &audioFeatures)
+	return audioFeatures, err
 }
 
 // Get the audio analysis of a track
 // Returned values include duration, number of samples, and loudness
-func (u *User) GetTrackAudioAnalysis(q Query, i interface{}) (AudioAnalysis, bool) {
-	uri := ""
-	ok := true
-
-	switch v := i.(type) {
-	case string:
-		bytes, searchErr := q.search(v, "track")
-		if searchErr != nil {
-			ok = false
-		}
-		searchResult := searchResult{}
-		jsonErr := json.Unmarshal(bytes, &searchResult)
+func (u *User) GetTrackAudioAnalysis(q Query, i interface{}) (AudioAnalysis, error) {
+	return u.GetTrackAudioAnalysisContext(context.Background(), q, i)
+}
 
-		if jsonErr != nil {
-			log.Fatal("JSON Error:", jsonErr)
-		}
+// GetTrackAudioAnalysisContext is GetTrackAudioAnalysis with a context
+func (u *User) GetTrackAudioAnalysisContext(ctx context.Context, q Query, i interface{}) (_ AudioAnalysis, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetTrackAudioAnalysis")
+	defer func() { span.endItems(err, 1) }()
 
-		if searchResult.Tracks.Total > 0 {
-			track := searchResult.Tracks.Items[0]
-			uri = track.ID
-		}
-	case Track:
-		uri = v.ID
-	default:
-		ok = false
-		fmt.Println("Invalid Type")
+	audioAnalysis := AudioAnalysis{}
+	uri, err := q.trackID(ctx, i)
+	if err != nil {
+		return audioAnalysis, err
 	}
 	reqURL := u.baseURL + "audio-analysis/" + uri
-	fmt.Println("requrl", reqURL)
 
-	audioAnalysis := AudioAnalysis{}
-	ok = u.sendGetRequest(reqURL, &audioAnalysis) && ok
-	return audioAnalysis, ok
+	err = u.sendGetRequest(ctx, reqURL, &audioAnalysis)
+	return audioAnalysis, err
 }
 
 // Audio Features struct- maps to Spotify JSON response format by tag `json: "var_name"`
@@ -481,10 +579,21 @@ type AudioAnalysis struct {
 	} `json:"tatums"`
 }
 
-func (u *User) GetCurrentProfile() (Profile, bool) {
+func (u *User) GetCurrentProfile() (Profile, error) {
+	return u.GetCurrentProfileContext(context.Background())
+}
+
+// GetCurrentProfileContext is GetCurrentProfile with a context
+func (u *User) GetCurrentProfileContext(ctx context.Context) (_ Profile, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetCurrentProfile")
+	defer func() { span.endItems(err, 1) }()
+
+	if err := u.checkScopes("GetCurrentProfile"); err != nil {
+		return Profile{}, err
+	}
 	reqURL := u.baseURL + "me"
 
 	profile := Profile{}
-	ok := u.sendGetRequest(reqURL, &profile)
-	return profile, ok
+	err = u.sendGetRequest(ctx, reqURL, &profile)
+	return profile, err
 }
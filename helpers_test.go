@@ -0,0 +1,144 @@
+package spotigo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+)
+
+// Start a fake Spotify server, closed when the test ends
+func newServer(t *testing.T) *spotigotest.Server {
+	t.Helper()
+	srv := spotigotest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Options pointing a client at srv, followed by opts
+// Each client gets its own unpaced RateLimiter with short back-offs, so tests
+// don't share the default limiter or wait on each other
+func testOptions(srv *spotigotest.Server, opts ...spotigo.Option) []spotigo.Option {
+	limiter := spotigo.NewRateLimiter(spotigo.RequestRate(0, 0), spotigo.RetryBackoff(time.Millisecond, 10*time.Millisecond))
+	return append(append(srv.Options(), spotigo.WithRateLimiter(limiter)), opts...)
+}
+
+// Create a Query against srv
+func newQuery(t *testing.T, srv *spotigotest.Server, opts ...spotigo.Option) spotigo.Query {
+	t.Helper()
+	q, err := spotigo.NewQuery("id", "secret", testOptions(srv, opts...)...)
+	if err != nil {
+		t.Fatalf("NewQuery: %v", err)
+	}
+	return q
+}
+
+// Create a User logged in through srv's authorize endpoint
+func newUser(t *testing.T, srv *spotigotest.Server, opts ...spotigo.Option) *spotigo.User {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts = append([]spotigo.Option{spotigo.WithAuthPrompt(approve(srv))}, opts...)
+	u, err := spotigo.NewUserPKCEContext(ctx, "id", testOptions(srv, opts...)...)
+	if err != nil {
+		t.Fatalf("NewUserPKCE: %v", err)
+	}
+	return u
+}
+
+// An AuthPrompt approving the login at srv and pasting the URL it redirects to
+func approve(srv *spotigotest.Server) spotigo.AuthPrompt {
+	client := *srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return func(ctx context.Context, authURL string) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, authURL, nil)
+		if err != nil {
+			return "", err
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		res.Body.Close()
+		return res.Header.Get("Location"), nil
+	}
+}
+
+// A Track by one artist
+func newTrack(id, name, artist string, popularity int) spotigo.Track {
+	var t spotigo.Track
+	b, _ := json.Marshal(map[string]interface{}{
+		"id":         id,
+		"name":       name,
+		"popularity": popularity,
+		"artists":    []map[string]string{{"id": strings.ToLower(strings.ReplaceAll(artist, " ", "")), "name": artist}},
+	})
+	json.Unmarshal(b, &t)
+	return t
+}
+
+// Number of requests srv has served that start with prefix, e.g. "GET /v1/tracks/"
+func countRequests(srv *spotigotest.Server, prefix string) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if strings.HasPrefix(r, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// recordingTracer struct- a Tracer keeping every span it starts
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+// recordedSpan struct- a span of a recordingTracer
+type recordedSpan struct {
+	mu    sync.Mutex
+	name  string
+	attrs map[string]interface{}
+}
+
+func (tr *recordingTracer) Start(ctx context.Context, name string) (context.Context, spotigo.Span) {
+	s := &recordedSpan{name: name, attrs: make(map[string]interface{})}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.spans = append(tr.spans, s)
+	return ctx, s
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...spotigo.Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(error) {}
+func (s *recordedSpan) End()              {}
+
+// The value of attribute key on each span named name, in the order started
+func (tr *recordingTracer) attr(name, key string) []interface{} {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	values := make([]interface{}, 0)
+	for _, s := range tr.spans {
+		s.mu.Lock()
+		if s.name == name {
+			values = append(values, s.attrs[key])
+		}
+		s.mu.Unlock()
+	}
+	return values
+}
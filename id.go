@@ -0,0 +1,168 @@
+package spotigo
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// EntityType is the kind of item an ID refers to
+type EntityType string
+
+const (
+	EntityTrack     EntityType = "track"
+	EntityAlbum     EntityType = "album"
+	EntityArtist    EntityType = "artist"
+	EntityPlaylist  EntityType = "playlist"
+	EntityShow      EntityType = "show"
+	EntityEpisode   EntityType = "episode"
+	EntityAudiobook EntityType = "audiobook"
+	EntityUser      EntityType = "user"
+)
+
+// ID struct- a Spotify ID with the type of item it refers to
+// Type is empty for an ID parsed from a bare base-62 ID
+type ID struct {
+	Type EntityType
+	ID   string
+}
+
+// Spotify IDs are base-62, normally 22 characters; user IDs are usernames,
+// which may contain more
+var (
+	base62Pattern = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+	userPattern   = regexp.MustCompile(`^[0-9A-Za-z._-]+$`)
+)
+
+// Length of the IDs Spotify issues today
+const idLength = 22
+
+// Parse an item's ID from any of the forms Spotify shows it in:
+//
+//	4uLU6hMCjMI75M1A2tKUQC
+//	spotify:track:4uLU6hMCjMI75M1A2tKUQC
+//	https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC?si=...
+//
+// Legacy playlist URIs and URLs that include the owner, and open.spotify.com
+// URLs with a locale such as /intl-de/, are accepted too
+func ParseID(s string) (ID, error) {
+	s = strings.TrimSpace(s)
+
+	var parts []string
+	switch {
+	case strings.HasPrefix(s, "spotify:"):
+		parts = strings.Split(strings.TrimPrefix(s, "spotify:"), ":")
+	case strings.Contains(s, "open.spotify.com/"):
+		if !strings.Contains(s, "://") {
+			s = "https://" + s
+		}
+		u, err := url.Parse(s)
+		if err != nil || u.Host != "open.spotify.com" {
+			return ID{}, invalidInput("%q isn't a Spotify URL", s)
+		}
+		parts = strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) > 0 && strings.HasPrefix(parts[0], "intl-") {
+			parts = parts[1:]
+		}
+	default:
+		if !base62Pattern.MatchString(s) {
+			return ID{}, invalidInput("%q isn't a Spotify ID, URI or URL", s)
+		}
+		return ID{ID: s}, nil
+	}
+
+	// spotify:user:{owner}:playlist:{id}
+	if len(parts) == 4 && parts[0] == string(EntityUser) && parts[2] == string(EntityPlaylist) {
+		parts = parts[2:]
+	}
+	if len(parts) != 2 {
+		return ID{}, invalidInput("%q isn't a Spotify URI or URL of a single item", s)
+	}
+
+	id := ID{Type: EntityType(parts[0]), ID: parts[1]}
+	switch id.Type {
+	case EntityUser:
+		if !userPattern.MatchString(id.ID) {
+			return ID{}, invalidInput("bad user ID in %q", s)
+		}
+	case EntityTrack, EntityAlbum, EntityArtist, EntityPlaylist, EntityShow, EntityEpisode, EntityAudiobook:
+		if !base62Pattern.MatchString(id.ID) {
+			return ID{}, invalidInput("bad %s ID in %q", id.Type, s)
+		}
+	default:
+		return ID{}, invalidInput("unknown item type %q in %q", id.Type, s)
+	}
+	return id, nil
+}
+
+// Parse an ID of the given type, or a bare ID that is assumed to be one
+func parseIDOf(s string, typ EntityType) (string, error) {
+	id, err := ParseID(s)
+	if err != nil {
+		return "", err
+	}
+	return id.of(typ)
+}
+
+// The bare ID, if id refers to an item of type typ
+func (id ID) of(typ EntityType) (string, error) {
+	if id.Type != "" && id.Type != typ {
+		return "", invalidInput("expected %s ID, got %s ID", typ, id.Type)
+	}
+	if id.ID == "" {
+		return "", invalidInput("empty %s ID", typ)
+	}
+	return id.ID, nil
+}
+
+// The Spotify URI, e.g. spotify:track:4uLU6hMCjMI75M1A2tKUQC, or the bare ID
+// if the type is unknown
+func (id ID) URI() string {
+	if id.Type == "" {
+		return id.ID
+	}
+	return uriOf(id.Type, id.ID)
+}
+
+// The open.spotify.com URL, or "" if the type is unknown
+func (id ID) URL() string {
+	if id.Type == "" {
+		return ""
+	}
+	return "https://open.spotify.com/" + string(id.Type) + "/" + id.ID
+}
+
+// The URI, so an ID can be passed wherever a URI string is expected
+func (id ID) String() string {
+	return id.URI()
+}
+
+// The Spotify URI of an item, or "" if its ID is unknown
+func uriOf(typ EntityType, id string) string {
+	if id == "" {
+		return ""
+	}
+	return "spotify:" + string(typ) + ":" + id
+}
+
+// Whether a string is a Spotify URI or URL rather than a search query
+func isSpotifyLink(s string) bool {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"spotify:", "https://open.spotify.com/", "http://open.spotify.com/", "open.spotify.com/"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether a string is a Spotify URI, URL or bare ID rather than a search query
+// Only 22-character bare IDs are recognized, so shorter words such as "Juno"
+// are still searched for
+func isSpotifyRef(s string) bool {
+	if isSpotifyLink(s) {
+		return true
+	}
+	s = strings.TrimSpace(s)
+	return len(s) == idLength && base62Pattern.MatchString(s)
+}
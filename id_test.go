@@ -0,0 +1,90 @@
+package spotigo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+)
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want spotigo.ID
+	}{
+		{"4uLU6hMCjMI75M1A2tKUQC", spotigo.ID{ID: "4uLU6hMCjMI75M1A2tKUQC"}},
+		{"  4uLU6hMCjMI75M1A2tKUQC\n", spotigo.ID{ID: "4uLU6hMCjMI75M1A2tKUQC"}},
+		{"spotify:track:4uLU6hMCjMI75M1A2tKUQC", spotigo.ID{Type: spotigo.EntityTrack, ID: "4uLU6hMCjMI75M1A2tKUQC"}},
+		{"spotify:album:1DFixLWuPkv3KT3TnV35m3", spotigo.ID{Type: spotigo.EntityAlbum, ID: "1DFixLWuPkv3KT3TnV35m3"}},
+		{"spotify:user:adam.gamba", spotigo.ID{Type: spotigo.EntityUser, ID: "adam.gamba"}},
+		{"spotify:user:spotify:playlist:37i9dQZF1DXcBWIGoYBM5M", spotigo.ID{Type: spotigo.EntityPlaylist, ID: "37i9dQZF1DXcBWIGoYBM5M"}},
+		{"https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC?si=abc123", spotigo.ID{Type: spotigo.EntityTrack, ID: "4uLU6hMCjMI75M1A2tKUQC"}},
+		{"https://open.spotify.com/intl-de/artist/0TnOYISbd1XYRBk9myaseg", spotigo.ID{Type: spotigo.EntityArtist, ID: "0TnOYISbd1XYRBk9myaseg"}},
+		{"open.spotify.com/episode/512ojhOuo1ktJprKbVcKyQ", spotigo.ID{Type: spotigo.EntityEpisode, ID: "512ojhOuo1ktJprKbVcKyQ"}},
+		{"https://open.spotify.com/user/spotify/playlist/37i9dQZF1DXcBWIGoYBM5M", spotigo.ID{Type: spotigo.EntityPlaylist, ID: "37i9dQZF1DXcBWIGoYBM5M"}},
+	}
+	for _, tt := range tests {
+		got, err := spotigo.ParseID(tt.in)
+		if err != nil {
+			t.Errorf("ParseID(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseID(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseIDInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"not an id",
+		"spotify:track:",
+		"spotify:track:abc:def",
+		"spotify:podcast:4uLU6hMCjMI75M1A2tKUQC",
+		"spotify:track:4uLU6hMC-jMI75",
+		"https://example.com/track/4uLU6hMCjMI75M1A2tKUQC",
+		"https://open.spotify.com/",
+	} {
+		if id, err := spotigo.ParseID(in); !errors.Is(err, spotigo.ErrInvalidInput) {
+			t.Errorf("ParseID(%q) = %+v, %v, want ErrInvalidInput", in, id, err)
+		}
+	}
+}
+
+func TestIDURIAndURL(t *testing.T) {
+	id := spotigo.ID{Type: spotigo.EntityTrack, ID: "4uLU6hMCjMI75M1A2tKUQC"}
+	if got, want := id.URI(), "spotify:track:4uLU6hMCjMI75M1A2tKUQC"; got != want {
+		t.Errorf("URI() = %q, want %q", got, want)
+	}
+	if got, want := id.URL(), "https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+	if bare := (spotigo.ID{ID: "4uLU6hMCjMI75M1A2tKUQC"}); bare.URI() != bare.ID || bare.URL() != "" {
+		t.Errorf("bare ID: URI() = %q, URL() = %q", bare.URI(), bare.URL())
+	}
+}
+
+func TestLinksResolveThroughServer(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("4uLU6hMCjMI75M1A2tKUQC", "Disco Man", "Remi Wolf", 60))
+	q := newQuery(t, srv)
+
+	for _, link := range []string{
+		"spotify:track:4uLU6hMCjMI75M1A2tKUQC",
+		"https://open.spotify.com/intl-fr/track/4uLU6hMCjMI75M1A2tKUQC?si=x",
+	} {
+		track, err := q.GetTrackByURI(link)
+		if err != nil {
+			t.Errorf("GetTrackByURI(%q): %v", link, err)
+			continue
+		}
+		if track.Name != "Disco Man" {
+			t.Errorf("GetTrackByURI(%q) = %q, want Disco Man", link, track.Name)
+		}
+	}
+
+	if _, err := q.GetTrackByURI("spotify:album:4uLU6hMCjMI75M1A2tKUQC"); !errors.Is(err, spotigo.ErrInvalidInput) {
+		t.Errorf("GetTrackByURI of an album URI: %v, want ErrInvalidInput", err)
+	}
+}
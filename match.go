@@ -0,0 +1,429 @@
+package spotigo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Name resolution: instead of taking the first search result, several
+// candidates are fetched and scored against the query, and the best one is
+// returned with a confidence between 0 and 1
+
+// Number of candidates fetched for a match by default
+const defaultMatchCandidates = 10
+
+// MatchOption configures a match
+type MatchOption func(*matchParams)
+
+// Parameters of a match
+type matchParams struct {
+	artist     string
+	duration   time.Duration
+	candidates int
+	threshold  float64
+}
+
+// Prefer results by this artist
+func HintArtist(name string) MatchOption {
+	return func(p *matchParams) {
+		p.artist = name
+	}
+}
+
+// Prefer tracks close to this duration
+func HintDuration(d time.Duration) MatchOption {
+	return func(p *matchParams) {
+		p.duration = d
+	}
+}
+
+// Score up to n search results, 1 to 50 (default 10)
+func MatchCandidates(n int) MatchOption {
+	return func(p *matchParams) {
+		p.candidates = n
+	}
+}
+
+// Fail with ErrLowConfidence if the best match's confidence is below threshold
+func MatchStrict(threshold float64) MatchOption {
+	return func(p *matchParams) {
+		p.threshold = threshold
+	}
+}
+
+// TrackMatch struct- the best Track for a query and how well it matched
+type TrackMatch struct {
+	Track      Track
+	Confidence float64
+}
+
+// AlbumMatch struct- the best Album for a query and how well it matched
+type AlbumMatch struct {
+	Album      Album
+	Confidence float64
+}
+
+// ArtistMatch struct- the best Artist for a query and how well it matched
+type ArtistMatch struct {
+	Artist     Artist
+	Confidence float64
+}
+
+// PlaylistMatch struct- the best Playlist for a query and how well it matched
+type PlaylistMatch struct {
+	Playlist   Playlist
+	Confidence float64
+}
+
+// Find the Track that best matches a search query
+// Candidates are scored on title and artist similarity, the duration hint
+// and popularity; live, remix, karaoke and similar versions are penalized
+// unless the query asks for them
+func (q Query) MatchTrack(query string, opts ...MatchOption) (TrackMatch, error) {
+	return q.MatchTrackContext(context.Background(), query, opts...)
+}
+
+// MatchTrackContext is MatchTrack with a context
+func (q Query) MatchTrackContext(ctx context.Context, query string, opts ...MatchOption) (_ TrackMatch, err error) {
+	ctx, span := q.engine.startCall(ctx, "MatchTrack")
+	defer func() { span.endItems(err, 1) }()
+
+	p := q.matchParams(opts)
+	res, err := q.candidates(ctx, query, SearchTypeTrack, p)
+	if err != nil {
+		return TrackMatch{}, err
+	}
+	mq := parseMatchQuery(query, p)
+
+	best := TrackMatch{Confidence: -1}
+	for _, t := range res.Tracks.Items {
+		artists := make([]string, 0, len(t.Artists))
+		for _, a := range t.Artists {
+			artists = append(artists, a.Name)
+		}
+		score := mq.score(t.Name, t.Album.Name, artists, t.Popularity, time.Duration(t.DurationMs)*time.Millisecond)
+		if score > best.Confidence {
+			best = TrackMatch{Track: t, Confidence: score}
+		}
+	}
+	if best.Confidence < 0 {
+		return TrackMatch{}, notFound("track", query)
+	}
+	return best, p.check(query, best.Track.Name, best.Confidence)
+}
+
+// Find the Album that best matches a search query
+func (q Query) MatchAlbum(query string, opts ...MatchOption) (AlbumMatch, error) {
+	return q.MatchAlbumContext(context.Background(), query, opts...)
+}
+
+// MatchAlbumContext is MatchAlbum with a context
+func (q Query) MatchAlbumContext(ctx context.Context, query string, opts ...MatchOption) (_ AlbumMatch, err error) {
+	ctx, span := q.engine.startCall(ctx, "MatchAlbum")
+	defer func() { span.endItems(err, 1) }()
+
+	p := q.matchParams(opts)
+	res, err := q.candidates(ctx, query, SearchTypeAlbum, p)
+	if err != nil {
+		return AlbumMatch{}, err
+	}
+	mq := parseMatchQuery(query, p)
+
+	best := AlbumMatch{Confidence: -1}
+	for _, a := range res.Albums.Items {
+		artists := make([]string, 0, len(a.Artists))
+		for _, artist := range a.Artists {
+			artists = append(artists, artist.Name)
+		}
+		score := mq.score(a.Name, "", artists, a.Popularity, 0)
+		if score > best.Confidence {
+			best = AlbumMatch{Album: a, Confidence: score}
+		}
+	}
+	if best.Confidence < 0 {
+		return AlbumMatch{}, notFound("album", query)
+	}
+	return best, p.check(query, best.Album.Name, best.Confidence)
+}
+
+// Find the Artist that best matches a search query
+func (q Query) MatchArtist(query string, opts ...MatchOption) (ArtistMatch, error) {
+	return q.MatchArtistContext(context.Background(), query, opts...)
+}
+
+// MatchArtistContext is MatchArtist with a context
+func (q Query) MatchArtistContext(ctx context.Context, query string, opts ...MatchOption) (_ ArtistMatch, err error) {
+	ctx, span := q.engine.startCall(ctx, "MatchArtist")
+	defer func() { span.endItems(err, 1) }()
+
+	p := q.matchParams(opts)
+	res, err := q.candidates(ctx, query, SearchTypeArtist, p)
+	if err != nil {
+		return ArtistMatch{}, err
+	}
+	mq := parseMatchQuery(query, p)
+
+	best := ArtistMatch{Confidence: -1}
+	for _, a := range res.Artists.Items {
+		score := mq.score(a.Name, "", nil, a.Popularity, 0)
+		if score > best.Confidence {
+			best = ArtistMatch{Artist: a, Confidence: score}
+		}
+	}
+	if best.Confidence < 0 {
+		return ArtistMatch{}, notFound("artist", query)
+	}
+	return best, p.check(query, best.Artist.Name, best.Confidence)
+}
+
+// Find the Playlist that best matches a search query
+func (q Query) MatchPlaylist(query string, opts ...MatchOption) (PlaylistMatch, error) {
+	return q.MatchPlaylistContext(context.Background(), query, opts...)
+}
+
+// MatchPlaylistContext is MatchPlaylist with a context
+func (q Query) MatchPlaylistContext(ctx context.Context, query string, opts ...MatchOption) (_ PlaylistMatch, err error) {
+	ctx, span := q.engine.startCall(ctx, "MatchPlaylist")
+	defer func() { span.endItems(err, 1) }()
+
+	p := q.matchParams(opts)
+	res, err := q.candidates(ctx, query, SearchTypePlaylist, p)
+	if err != nil {
+		return PlaylistMatch{}, err
+	}
+	mq := parseMatchQuery(query, p)
+
+	best := PlaylistMatch{Confidence: -1}
+	for _, pl := range res.Playlists.Items {
+		score := mq.score(pl.Name, "", []string{pl.Owner.DisplayName}, -1, 0)
+		if score > best.Confidence {
+			best = PlaylistMatch{Playlist: pl, Confidence: score}
+		}
+	}
+	if best.Confidence < 0 {
+		return PlaylistMatch{}, notFound("playlist", query)
+	}
+	return best, p.check(query, best.Playlist.Name, best.Confidence)
+}
+
+// Build match parameters from the Query's defaults and opts
+func (q Query) matchParams(opts []MatchOption) matchParams {
+	p := matchParams{candidates: defaultMatchCandidates, threshold: q.matchThreshold}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// Fetch the candidates for a match
+func (q Query) candidates(ctx context.Context, query string, t SearchType, p matchParams) (*SearchResult, error) {
+	if p.candidates < 1 || p.candidates > maxSearchLimit {
+		return nil, invalidInput("match candidates %d out of range 1 to %d", p.candidates, maxSearchLimit)
+	}
+	return q.searchPage(ctx, query, t, searchParams{limit: p.candidates})
+}
+
+// Return ErrLowConfidence if confidence is below the threshold
+func (p matchParams) check(query, name string, confidence float64) error {
+	if confidence < p.threshold {
+		return fmt.Errorf("%w: best match for %q was %q with confidence %.2f, below %.2f",
+			ErrLowConfidence, query, name, confidence, p.threshold)
+	}
+	return nil
+}
+
+// Weights of the parts of a score; they add up to 1
+const (
+	titleWeight      = 0.55
+	artistWeight     = 0.25
+	durationWeight   = 0.1
+	popularityWeight = 0.1
+)
+
+// Words marking a version other than the original recording, and the factor
+// a candidate's score is multiplied by when the query doesn't mention them
+var versionPenalties = map[string]float64{
+	"karaoke":      0.4,
+	"tribute":      0.5,
+	"cover":        0.6,
+	"instrumental": 0.6,
+	"live":         0.7,
+	"remix":        0.7,
+	"acoustic":     0.8,
+	"demo":         0.8,
+	"remastered":   0.9,
+	"remaster":     0.9,
+}
+
+// Field filters in a query: artist:"Remi Wolf" or year:2020
+var filterPattern = regexp.MustCompile(`(\w+):("[^"]*"|\S+)`)
+
+// A query prepared for scoring candidates
+type matchQuery struct {
+	// Every word of the query, filters included
+	words map[string]bool
+	// Words of the track:/album: filter, if any
+	title []string
+	// Words of the artist: filter or artist hint, if any
+	artist   []string
+	duration time.Duration
+}
+
+// Prepare a query, taking title and artist from its field filters if set
+func parseMatchQuery(query string, p matchParams) matchQuery {
+	mq := matchQuery{words: make(map[string]bool), duration: p.duration}
+	plain := query
+	for _, m := range filterPattern.FindAllStringSubmatch(query, -1) {
+		field, value := strings.ToLower(m[1]), strings.Trim(m[2], `"`)
+		switch field {
+		case "track", "album":
+			mq.title = words(value)
+		case "artist":
+			mq.artist = words(value)
+		}
+		plain = strings.Replace(plain, m[0], value, 1)
+	}
+	for _, w := range words(plain) {
+		mq.words[w] = true
+	}
+	if p.artist != "" {
+		mq.artist = words(p.artist)
+	}
+	return mq
+}
+
+// Score a candidate between 0 and 1
+// popularity is -1 for items without one; duration is 0 if unknown
+func (mq matchQuery) score(name, album string, artists []string, popularity int, duration time.Duration) float64 {
+	// Compare against the name without a " - Live" or "(Remix)" suffix
+	base := words(baseTitle(name))
+	var title float64
+	if len(mq.title) > 0 {
+		title = dice(mq.title, base)
+	} else {
+		// The title's words should all be in the query, and the title, album
+		// and artists together should explain the whole query
+		query := make([]string, 0, len(mq.words))
+		for w := range mq.words {
+			query = append(query, w)
+		}
+		known := words(name + " " + album + " " + strings.Join(artists, " "))
+		title = (coverage(query, base) + coverage(known, query)) / 2
+	}
+
+	artist := 1.0
+	if len(mq.artist) > 0 {
+		artist = 0
+		for _, a := range artists {
+			if s := dice(mq.artist, words(a)); s > artist {
+				artist = s
+			}
+		}
+	} else if len(artists) > 0 {
+		// Words of the query that aren't in the title should name the artist
+		extra := make([]string, 0)
+		titleWords := setOf(words(name))
+		for w := range mq.words {
+			if !titleWords[w] {
+				extra = append(extra, w)
+			}
+		}
+		if len(extra) > 0 {
+			artist = 0
+			for _, a := range artists {
+				if s := coverage(words(a), extra); s > artist {
+					artist = s
+				}
+			}
+		}
+	}
+
+	durationScore := 1.0
+	if mq.duration > 0 && duration > 0 {
+		diff := mq.duration - duration
+		if diff < 0 {
+			diff = -diff
+		}
+		durationScore = 1 - float64(diff)/float64(30*time.Second)
+		if durationScore < 0 {
+			durationScore = 0
+		}
+	}
+
+	pop := 0.5
+	if popularity >= 0 {
+		pop = float64(popularity) / 100
+	}
+
+	score := titleWeight*title + artistWeight*artist + durationWeight*durationScore + popularityWeight*pop
+
+	// Penalize versions the query didn't ask for
+	version := setOf(words(name + " " + album))
+	for w, factor := range versionPenalties {
+		if version[w] && !mq.words[w] {
+			score *= factor
+		}
+	}
+	return score
+}
+
+// Lower-cased words of s, split on anything but letters and digits
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Name without a version suffix such as " - Live" or " (Remix)"
+func baseTitle(name string) string {
+	for _, sep := range []string{" - ", " (", " ["} {
+		if i := strings.Index(name, sep); i > 0 {
+			name = name[:i]
+		}
+	}
+	return name
+}
+
+// Set of words
+func setOf(ws []string) map[string]bool {
+	set := make(map[string]bool, len(ws))
+	for _, w := range ws {
+		set[w] = true
+	}
+	return set
+}
+
+// Dice similarity of two lists of words: 1 if they have the same words,
+// 0 if they share none
+func dice(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inB := setOf(b)
+	shared := 0
+	for w := range setOf(a) {
+		if inB[w] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(setOf(a))+len(inB))
+}
+
+// Fraction of the words of want that appear in have
+func coverage(have, want []string) float64 {
+	if len(want) == 0 {
+		return 0
+	}
+	inHave := setOf(have)
+	found := 0
+	for _, w := range want {
+		if inHave[w] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(want))
+}
@@ -0,0 +1,102 @@
+package spotigo_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+)
+
+func TestMatchTrack(t *testing.T) {
+	srv := newServer(t)
+	original := newTrack("orig", "Disco Man", "Remi Wolf", 60)
+	original.DurationMs = 210000
+	radio := newTrack("radio", "Disco Man - Radio Edit", "Remi Wolf", 40)
+	radio.DurationMs = 180000
+	srv.AddTrack(
+		original,
+		radio,
+		newTrack("karaoke", "Disco Man (Karaoke Version)", "Sing King", 95),
+		newTrack("live", "Disco Man - Live", "Remi Wolf", 80),
+		newTrack("other", "Disco Man", "The Discos", 30),
+	)
+	q := newQuery(t, srv)
+
+	tests := []struct {
+		query string
+		opts  []spotigo.MatchOption
+		want  string
+	}{
+		// Karaoke and live versions lose despite their popularity
+		{"Disco Man", nil, "orig"},
+		// Extra words of the query should name the artist
+		{"Disco Man Remi Wolf", nil, "orig"},
+		{"Disco Man The Discos", nil, "other"},
+		{`track:"Disco Man" artist:"The Discos"`, nil, "other"},
+		{"Disco Man", []spotigo.MatchOption{spotigo.HintArtist("The Discos")}, "other"},
+		// A version the query asks for isn't penalized
+		{"Disco Man karaoke", nil, "karaoke"},
+		{"Disco Man live", nil, "live"},
+		{"Disco Man Remi Wolf", []spotigo.MatchOption{spotigo.HintDuration(3 * time.Minute)}, "radio"},
+	}
+	for _, tt := range tests {
+		m, err := q.MatchTrack(tt.query, tt.opts...)
+		if err != nil {
+			t.Errorf("MatchTrack(%q): %v", tt.query, err)
+			continue
+		}
+		if m.Track.ID != tt.want {
+			t.Errorf("MatchTrack(%q) = %s (%.2f), want %s", tt.query, m.Track.ID, m.Confidence, tt.want)
+		}
+		if m.Confidence <= 0 || m.Confidence > 1 {
+			t.Errorf("MatchTrack(%q) confidence %v out of range (0, 1]", tt.query, m.Confidence)
+		}
+	}
+}
+
+func TestMatchConfidence(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(
+		newTrack("exact", "Disco Man", "Remi Wolf", 50),
+		newTrack("loose", "Disco Inferno", "The Trammps", 50),
+	)
+	q := newQuery(t, srv)
+
+	exact, err := q.MatchTrack("Disco Man Remi Wolf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loose, err := q.MatchTrack("Disco Trammps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loose.Track.ID != "loose" || loose.Confidence >= exact.Confidence {
+		t.Errorf("loose match %s (%.2f) should score below exact match %s (%.2f)", loose.Track.ID, loose.Confidence, exact.Track.ID, exact.Confidence)
+	}
+
+	_, err = q.MatchTrack("Disco Trammps", spotigo.MatchStrict(exact.Confidence))
+	if !errors.Is(err, spotigo.ErrLowConfidence) {
+		t.Errorf("MatchStrict: got %v, want ErrLowConfidence", err)
+	}
+	strict := newQuery(t, srv, spotigo.WithMatchThreshold(exact.Confidence))
+	if _, err := strict.GetTrackByName("Disco Trammps"); !errors.Is(err, spotigo.ErrLowConfidence) {
+		t.Errorf("WithMatchThreshold: got %v, want ErrLowConfidence", err)
+	}
+	if _, err := strict.GetTrackByName("Disco Man Remi Wolf"); err != nil {
+		t.Errorf("WithMatchThreshold, exact match: %v", err)
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	q := newQuery(t, srv)
+
+	if _, err := q.MatchTrack("Nothing Like It"); !errors.Is(err, spotigo.ErrNotFound) {
+		t.Errorf("no results: got %v, want ErrNotFound", err)
+	}
+	if _, err := q.MatchTrack("Disco Man", spotigo.MatchCandidates(51)); !errors.Is(err, spotigo.ErrInvalidInput) {
+		t.Errorf("MatchCandidates(51): got %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,259 @@
+package spotigo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsCollector records how a Query or User uses the Web API
+// Implementations must be safe for concurrent use
+type MetricsCollector interface {
+	// ObserveRequest is called after each attempt at a request, with the
+	// endpoint's path template (e.g. "playlists/{id}/tracks"); status is 0
+	// if the attempt failed without a response
+	ObserveRequest(method, endpoint string, status int, latency time.Duration)
+	// ObserveRetry is called before a failed attempt is retried, with the
+	// status that caused the retry, or 0 after a network error
+	ObserveRetry(method, endpoint string, status int)
+}
+
+// Record the requests of a Query or User in collector
+// Several Queries and Users can share one collector
+func WithMetrics(collector MetricsCollector) Option {
+	return func(o *options) {
+		o.metrics = collector
+	}
+}
+
+// Middleware reporting each attempt at a request to collector
+func metricsMiddleware(collector MetricsCollector) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.RoundTrip(req)
+			status := 0
+			if err == nil {
+				status = res.StatusCode
+			}
+			collector.ObserveRequest(req.Method, endpointOf(req), status, time.Since(start))
+			return res, err
+		})
+	}
+}
+
+// Top-level collections whose second path segment is an item's ID
+var idCollections = map[string]bool{
+	"albums": true, "artists": true, "audio-analysis": true, "audio-features": true,
+	"audiobooks": true, "chapters": true, "episodes": true, "playlists": true,
+	"shows": true, "tracks": true, "users": true,
+}
+
+// The path template of a Web API request, with IDs replaced by {id}, so that
+// requests for different items are counted together
+func endpointOf(req *http.Request) string {
+	return endpointOfPath(req.URL.Path)
+}
+
+// The path template of a Web API URL path
+func endpointOfPath(path string) string {
+	path = strings.Trim(path, "/")
+	if i := strings.Index(path, "v1/"); i >= 0 && (i == 0 || path[i-1] == '/') {
+		path = path[i+len("v1/"):]
+	} else if path == "v1" {
+		path = ""
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) >= 2 && idCollections[parts[0]] {
+		parts[1] = "{id}"
+	}
+	if len(parts) >= 3 && parts[0] == "browse" && parts[1] == "categories" {
+		parts[2] = "{id}"
+	}
+	return strings.Join(parts, "/")
+}
+
+// Upper bounds of the latency histogram buckets, in seconds
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// MemoryMetrics is a MetricsCollector that keeps its metrics in memory and
+// serves them in the Prometheus text format:
+//
+//	spotify_requests_total{method,endpoint,status}         requests by status; status "error" for network errors
+//	spotify_request_duration_seconds{method,endpoint}      latency histogram
+//	spotify_rate_limited_total{method,endpoint}            429 responses
+//	spotify_retries_total{method,endpoint}                 retried attempts
+type MemoryMetrics struct {
+	mu        sync.Mutex
+	requests  map[requestLabels]uint64
+	latencies map[endpointLabels]*histogram
+	limited   map[endpointLabels]uint64
+	retries   map[endpointLabels]uint64
+}
+
+// Labels of a request count
+type requestLabels struct {
+	method, endpoint, status string
+}
+
+// Labels of a per-endpoint metric
+type endpointLabels struct {
+	method, endpoint string
+}
+
+// Latency histogram: counts per bucket (not cumulative), then the total
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// Create an empty MemoryMetrics
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{
+		requests:  make(map[requestLabels]uint64),
+		latencies: make(map[endpointLabels]*histogram),
+		limited:   make(map[endpointLabels]uint64),
+		retries:   make(map[endpointLabels]uint64),
+	}
+}
+
+// Record an attempt at a request
+func (m *MemoryMetrics) ObserveRequest(method, endpoint string, status int, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	code := "error"
+	if status != 0 {
+		code = strconv.Itoa(status)
+	}
+	m.requests[requestLabels{method, endpoint, code}]++
+
+	e := endpointLabels{method, endpoint}
+	h, ok := m.latencies[e]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(latencyBuckets))}
+		m.latencies[e] = h
+	}
+	seconds := latency.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+
+	if status == http.StatusTooManyRequests {
+		m.limited[e]++
+	}
+}
+
+// Record a retry
+func (m *MemoryMetrics) ObserveRetry(method, endpoint string, status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[endpointLabels{method, endpoint}]++
+}
+
+// Handler serves the metrics in the Prometheus text exposition format
+func (m *MemoryMetrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WritePrometheus(w)
+	})
+}
+
+// Write the metrics to w in the Prometheus text exposition format
+func (m *MemoryMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintln(b, "# HELP spotify_requests_total Spotify Web API requests sent, by response status.")
+	fmt.Fprintln(b, "# TYPE spotify_requests_total counter")
+	requests := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		requests = append(requests, l)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+	for _, l := range requests {
+		fmt.Fprintf(b, "spotify_requests_total{method=%s,endpoint=%s,status=%s} %d\n",
+			labelValue(l.method), labelValue(l.endpoint), labelValue(l.status), m.requests[l])
+	}
+
+	fmt.Fprintln(b, "# HELP spotify_request_duration_seconds Latency of Spotify Web API requests.")
+	fmt.Fprintln(b, "# TYPE spotify_request_duration_seconds histogram")
+	for _, l := range sortedEndpoints(m.latencies) {
+		h := m.latencies[l]
+		labels := fmt.Sprintf("method=%s,endpoint=%s", labelValue(l.method), labelValue(l.endpoint))
+		var cumulative uint64
+		for i, bound := range latencyBuckets {
+			cumulative += h.buckets[i]
+			fmt.Fprintf(b, "spotify_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(b, "spotify_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(b, "spotify_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "spotify_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	writeCounter(b, "spotify_rate_limited_total", "Spotify Web API requests rejected with 429 Too Many Requests.", m.limited)
+	writeCounter(b, "spotify_retries_total", "Spotify Web API request attempts retried.", m.retries)
+	return b.Flush()
+}
+
+// Write a per-endpoint counter
+func writeCounter(w io.Writer, name, help string, values map[endpointLabels]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, l := range sortedEndpoints(values) {
+		fmt.Fprintf(w, "%s{method=%s,endpoint=%s} %d\n", name, labelValue(l.method), labelValue(l.endpoint), values[l])
+	}
+}
+
+// The keys of a per-endpoint metric in a stable order
+func sortedEndpoints(m interface{}) []endpointLabels {
+	labels := make([]endpointLabels, 0)
+	switch m := m.(type) {
+	case map[endpointLabels]uint64:
+		for l := range m {
+			labels = append(labels, l)
+		}
+	case map[endpointLabels]*histogram:
+		for l := range m {
+			labels = append(labels, l)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].endpoint != labels[j].endpoint {
+			return labels[i].endpoint < labels[j].endpoint
+		}
+		return labels[i].method < labels[j].method
+	})
+	return labels
+}
+
+// Quote a label value, escaping as the text format requires
+func labelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return `"` + v + `"`
+}
@@ -0,0 +1,90 @@
+package spotigo_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50), newTrack("t2", "Juno", "Remi Wolf", 50))
+	m := spotigo.NewMemoryMetrics()
+	q := newQuery(t, srv, spotigo.WithMetrics(m))
+
+	srv.FailNext(1, http.StatusTooManyRequests, 0)
+	for _, id := range []string{"t1", "t2"} {
+		if _, err := q.GetTrackByURI(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := q.GetTrackByURI("missing"); err == nil {
+		t.Fatal("no error for a missing track")
+	}
+
+	var b bytes.Buffer
+	if err := m.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# HELP spotify_requests_total Spotify Web API requests sent, by response status.\n# TYPE spotify_requests_total counter\n",
+		`spotify_requests_total{method="GET",endpoint="tracks/{id}",status="200"} 2` + "\n",
+		`spotify_requests_total{method="GET",endpoint="tracks/{id}",status="404"} 1` + "\n",
+		`spotify_requests_total{method="GET",endpoint="tracks/{id}",status="429"} 1` + "\n",
+		"# TYPE spotify_request_duration_seconds histogram\n",
+		`spotify_request_duration_seconds_bucket{method="GET",endpoint="tracks/{id}",le="+Inf"} 4` + "\n",
+		`spotify_request_duration_seconds_count{method="GET",endpoint="tracks/{id}"} 4` + "\n",
+		`spotify_rate_limited_total{method="GET",endpoint="tracks/{id}"} 1` + "\n",
+		`spotify_retries_total{method="GET",endpoint="tracks/{id}"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	// Buckets are cumulative, ending at the count
+	if !strings.Contains(out, `spotify_request_duration_seconds_bucket{method="GET",endpoint="tracks/{id}",le="10"} 4`+"\n") {
+		t.Errorf("last bucket isn't cumulative:\n%s", out)
+	}
+}
+
+func TestMetricsCountTokenRefresh(t *testing.T) {
+	srv := newServer(t)
+	m := spotigo.NewMemoryMetrics()
+	u := newUser(t, srv, spotigo.WithMetrics(m), spotigo.WithScopes(spotigo.ScopeUserReadPrivate))
+
+	srv.ExpireTokens()
+	if _, err := u.GetCurrentProfile(); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	m.WritePrometheus(&b)
+	for _, want := range []string{
+		`spotify_requests_total{method="GET",endpoint="me",status="401"} 1` + "\n",
+		`spotify_requests_total{method="GET",endpoint="me",status="200"} 1` + "\n",
+		`spotify_retries_total{method="GET",endpoint="me"} 1` + "\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	m := spotigo.NewMemoryMetrics()
+	m.ObserveRequest("GET", `say "hi"`, 0, 0)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type %q", ct)
+	}
+	if want := `spotify_requests_total{method="GET",endpoint="say \"hi\"",status="error"} 1`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("output lacks %q:\n%s", want, rec.Body.String())
+	}
+}
@@ -0,0 +1,136 @@
+package spotigo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware wraps the transport that sends a Query's or User's requests
+// It sees every attempt at a Web API request as sent, after the rate limiter
+// and with the Authorization header set, and every response as received
+// Token requests to the Accounts Service, which carry secrets, bypass it
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper, for writing
+// Middleware
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// Call f
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Wrap the transport of a Query or User in middleware
+// The first Middleware given is the outermost: it sees a request first and
+// its response last. Repeated WithMiddleware options add to the chain
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// Wrap next in the middleware of o
+func (o options) wrapTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	for i := len(o.middleware) - 1; i >= 0; i-- {
+		next = o.middleware[i](next)
+	}
+	return next
+}
+
+// Logger is the subset of *slog.Logger that LoggingMiddleware writes to; a
+// *slog.Logger can be passed as is
+type Logger interface {
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// Log every request with its method, URL, status and latency as structured
+// attributes; requests that fail without a response are logged as errors
+// Query strings are logged, tokens are not
+func LoggingMiddleware(logger Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.RoundTrip(req)
+			latency := time.Since(start)
+
+			ctx := req.Context()
+			if err != nil {
+				logger.ErrorContext(ctx, "spotify request failed",
+					"method", req.Method, "url", req.URL.String(), "latency", latency, "error", err)
+				return res, err
+			}
+			logger.InfoContext(ctx, "spotify request",
+				"method", req.Method, "url", req.URL.String(), "status", res.StatusCode, "latency", latency)
+			return res, err
+		})
+	}
+}
+
+// Write every request and response to w in HTTP/1.1 wire format, including
+// their bodies if body is set
+// The Authorization header is redacted. Dumps of concurrent requests are
+// written one at a time, so they don't interleave
+func DumpMiddleware(w io.Writer, body bool) Middleware {
+	var mu sync.Mutex
+	write := func(dump []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(dump)
+		if len(dump) > 0 && dump[len(dump)-1] != '\n' {
+			io.WriteString(w, "\n")
+		}
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// Dump a copy, so the request sent keeps its token
+			redacted := req.Clone(req.Context())
+			if auth := req.Header.Get("Authorization"); auth != "" {
+				redacted.Header.Set("Authorization", redactAuthorization(auth))
+			}
+			// DumpRequestOut reads the body, which must then be replaced
+			if body && req.Body != nil && req.GetBody != nil {
+				if redacted.Body, _ = req.GetBody(); redacted.Body == nil {
+					redacted.Body = http.NoBody
+				}
+			} else {
+				redacted.Body = nil
+			}
+			dump, err := httputil.DumpRequestOut(redacted, body && redacted.Body != nil)
+			if err != nil {
+				dump = []byte(fmt.Sprintf("couldn't dump request: %v", err))
+			}
+			write(dump)
+
+			res, err := next.RoundTrip(req)
+			if err != nil {
+				write([]byte(fmt.Sprintf("%s %s failed: %v", req.Method, req.URL, err)))
+				return res, err
+			}
+			dump, err = httputil.DumpResponse(res, body)
+			if err != nil {
+				dump = []byte(fmt.Sprintf("couldn't dump response: %v", err))
+			}
+			write(dump)
+			return res, nil
+		})
+	}
+}
+
+// Replace the credentials of an Authorization header value, keeping its scheme
+func redactAuthorization(value string) string {
+	if i := strings.IndexByte(value, ' '); i >= 0 {
+		return value[:i] + " REDACTED"
+	}
+	return "REDACTED"
+}
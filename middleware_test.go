@@ -0,0 +1,128 @@
+package spotigo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+)
+
+func TestDumpMiddleware(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	srv.AddDevice(spotigotest.DefaultUserID, spotigo.Device{ID: "d1", Active: true})
+	var dump bytes.Buffer
+	q := newQuery(t, srv)
+	u := newUser(t, srv, spotigo.WithMiddleware(spotigo.DumpMiddleware(&dump, true)))
+
+	if err := u.PlayTrack(q, "spotify:track:t1"); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := u.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := dump.String()
+	if strings.Contains(out, tok.AccessToken) {
+		t.Errorf("dump contains the access token:\n%s", out)
+	}
+	for _, want := range []string{
+		"PUT /v1/me/player/play HTTP/1.1\r\n",
+		"Authorization: Bearer REDACTED\r\n",
+		`{"uris":["spotify:track:t1"]}`,
+		"HTTP/1.1 204 No Content\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump lacks %q:\n%s", want, out)
+		}
+	}
+	// The request itself kept its token and body
+	if !srv.Player(spotigotest.DefaultUserID).IsPlaying {
+		t.Error("track isn't playing")
+	}
+}
+
+// logEntry struct- one call to a fakeLogger
+type logEntry struct {
+	level string
+	msg   string
+	attrs map[string]interface{}
+}
+
+// fakeLogger struct- a Logger recording what it is given
+type fakeLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *fakeLogger) log(level, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	attrs := make(map[string]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[args[i].(string)] = args[i+1]
+	}
+	l.entries = append(l.entries, logEntry{level, msg, attrs})
+}
+
+func (l *fakeLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log("info", msg, args)
+}
+
+func (l *fakeLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.log("error", msg, args)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	logger := &fakeLogger{}
+	errDown := errors.New("network down")
+	failing := func(next http.RoundTripper) http.RoundTripper {
+		return spotigo.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/down") {
+				return nil, errDown
+			}
+			return next.RoundTrip(req)
+		})
+	}
+	q := newQuery(t, srv, spotigo.WithMiddleware(spotigo.LoggingMiddleware(logger), failing))
+
+	if _, err := q.Search("Disco", spotigo.SearchTypeTrack); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetTrackByURI("down"); !errors.Is(err, errDown) {
+		t.Fatalf("got %v, want the network error", err)
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.entries) < 2 {
+		t.Fatalf("%d log entries, want at least 2", len(logger.entries))
+	}
+	ok := logger.entries[0]
+	if ok.level != "info" || ok.msg != "spotify request" || ok.attrs["method"] != "GET" || ok.attrs["status"] != http.StatusOK {
+		t.Errorf("logged %+v", ok)
+	}
+	if u, _ := ok.attrs["url"].(string); !strings.Contains(u, "/v1/search?q=Disco") {
+		t.Errorf("logged URL %q", u)
+	}
+	if _, isDuration := ok.attrs["latency"].(time.Duration); !isDuration {
+		t.Errorf("latency %v isn't a time.Duration", ok.attrs["latency"])
+	}
+
+	// Every attempt at the failing request is logged as an error
+	for _, e := range logger.entries[1:] {
+		if e.level != "error" || e.attrs["error"] != errDown || e.attrs["status"] != nil {
+			t.Errorf("logged %+v", e)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package spotigo
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// Default base URL of the Spotify Web API
+	defaultAPIURL = "https://api.spotify.com/v1/"
+	// Default base URL of the Spotify Accounts Service
+	defaultAccountsURL = "https://accounts.spotify.com/"
+	// Default redirect URL for User logins, served by a local callback server
+	defaultRedirectURL = "http://localhost:8080/callback"
+	// Default time to wait for a User to complete a login in the browser
+	defaultAuthTimeout = 5 * time.Minute
+	// Default number of names the Get*ByNames methods resolve at once
+	defaultParallelism = 8
+)
+
+// options struct- settings shared by the Query and User constructors
+type options struct {
+	apiURL      string
+	accountsURL string
+	httpClient  *http.Client
+	scopes      []string
+	tokenStore  TokenStore
+	authPrompt  AuthPrompt
+	redirectURL string
+	authTimeout time.Duration
+	stateStore  StateStore
+
+	matchThreshold float64
+	parallelism    int
+
+	acceptLanguage string
+	middleware     []Middleware
+	metrics        MetricsCollector
+	tracer         Tracer
+	cache          Cache
+
+	rateLimiter    *RateLimiter
+	rateLimiterSet bool
+}
+
+// Option configures a Query or User when passed to NewQuery or NewUserWithOptions
+type Option func(*options)
+
+// Set the base URL of the Web API (default "https://api.spotify.com/v1/")
+// Useful for pointing the library at a local stand-in server
+func WithAPIURL(apiURL string) Option {
+	return func(o *options) {
+		o.apiURL = withTrailingSlash(apiURL)
+	}
+}
+
+// Set the base URL of the Accounts Service (default "https://accounts.spotify.com/")
+// Both the authorize and token endpoints are resolved against this URL
+func WithAccountsURL(accountsURL string) Option {
+	return func(o *options) {
+		o.accountsURL = withTrailingSlash(accountsURL)
+	}
+}
+
+// Use the given HTTP client for all requests to the Web API and Accounts Service
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// Set the scopes requested when authenticating a User
+// See scopes.go for details on Scopes
+func WithScopes(scopes ...string) Option {
+	return func(o *options) {
+		o.scopes = scopes
+	}
+}
+
+// Restore a User's token from store instead of logging in, and save new and
+// refreshed tokens to it
+func WithTokenStore(store TokenStore) Option {
+	return func(o *options) {
+		o.tokenStore = store
+	}
+}
+
+// Set the redirect URL for User logins (default "http://localhost:8080/callback")
+// It must be registered in the app's settings on the Spotify Developer Dashboard
+// The callback server listens on the URL's host and port; port 0 picks a free
+// port, for apps whose settings allow any loopback port
+func WithRedirectURL(redirectURL string) Option {
+	return func(o *options) {
+		o.redirectURL = redirectURL
+	}
+}
+
+// Set how long a User login waits for the browser redirect, or for the auth
+// prompt's answer, before failing with ErrLoginTimeout (default 5 minutes); 0 waits until the context is done
+func WithAuthTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.authTimeout = timeout
+	}
+}
+
+// Make a Query's name lookups strict: Get*ByName, and methods given a search
+// query instead of a struct, fail with ErrLowConfidence when the best match's
+// confidence is below threshold (see MatchTrack)
+func WithMatchThreshold(threshold float64) Option {
+	return func(o *options) {
+		o.matchThreshold = threshold
+	}
+}
+
+// Ask for names and descriptions in these languages, as an Accept-Language
+// header value such as "es-MX, es;q=0.8"
+func WithAcceptLanguage(lang string) Option {
+	return func(o *options) {
+		o.acceptLanguage = lang
+	}
+}
+
+// Resolve up to n names at once in the Get*ByNames methods (default 8)
+// Every lookup goes through the same client, so n bounds the requests in
+// flight rather than adding to them
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		o.parallelism = n
+	}
+}
+
+// Build options from defaults and the given Options
+func newOptions(opts []Option) options {
+	o := options{
+		apiURL:      defaultAPIURL,
+		accountsURL: defaultAccountsURL,
+		redirectURL: defaultRedirectURL,
+		authTimeout: defaultAuthTimeout,
+		parallelism: defaultParallelism,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Base URLs are joined with relative paths, so they must end in a slash
+func withTrailingSlash(s string) string {
+	if !strings.HasSuffix(s, "/") {
+		s += "/"
+	}
+	return s
+}
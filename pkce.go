@@ -0,0 +1,42 @@
+package spotigo
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"golang.org/x/oauth2"
+)
+
+// Proof Key for Code Exchange (RFC 7636), used by public clients that cannot
+// keep a client secret, such as desktop and command-line tools
+// See: https://developer.spotify.com/documentation/web-api/tutorials/code-pkce-flow
+
+// Generate a random code verifier
+// 32 random bytes give 43 base64url characters, the minimum length allowed
+func newCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// S256 code challenge for a verifier: base64url(sha256(verifier))
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Parameters that add a verifier's challenge to the authorization URL
+func challengeParams(verifier string) []oauth2.AuthCodeOption {
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", codeChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
+}
+
+// Parameter that sends the verifier with the token exchange
+func verifierParam(verifier string) oauth2.AuthCodeOption {
+	return oauth2.SetAuthURLParam("code_verifier", verifier)
+}
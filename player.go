@@ -1,95 +1,90 @@
 package spotigo
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 )
 
 // General method to send HTTP request given a method and URL
-func (u *User) sendRequest(method string, reqURL string) bool {
-	req, reqErr := http.NewRequest(method, reqURL, nil)
-	if reqErr != nil {
-		// fmt.Println("ReqErr:", reqErr)
-		return false
-	}
-
-	exErr := u.execute(req, nil, http.StatusNoContent)
-	if exErr != nil {
-		// fmt.Printf("ExErr for %s:%s\n", reqURL, exErr)
-		return false
-	}
-
-	return true
+func (u *User) sendRequest(ctx context.Context, method string, reqURL string) error {
+	return u.engine.do(ctx, method, reqURL, nil, nil)
 }
 
 // Send Get HTTP Request given a URL and parameters
-func (u *User) sendGetRequest(reqURL string, i interface{}) bool {
-	exErr := u.get(reqURL, &i)
-	if exErr != nil {
-		fmt.Printf("ExErr for %s: %s\n", reqURL, exErr)
-		return false
-	}
-
-	return true
+func (u *User) sendGetRequest(ctx context.Context, reqURL string, i interface{}) error {
+	return u.engine.get(ctx, reqURL, i)
 }
 
 // Pause playback for a User
-func (u *User) Pause() bool {
-	isPlaying, ok := u.IsPlaying()
-	if !isPlaying {
-		return true
+func (u *User) Pause() error {
+	return u.PauseContext(context.Background())
+}
+
+// PauseContext is Pause with a context
+func (u *User) PauseContext(ctx context.Context) (err error) {
+	ctx, span := u.engine.startCall(ctx, "Pause")
+	defer span.end(&err)
+
+	if err := u.checkScopes("Pause"); err != nil {
+		return err
+	}
+	// Skip the command if playback is already paused, when the User
+	// granted a scope to read it
+	if u.checkScopes("IsPlaying") == nil {
+		isPlaying, err := u.IsPlayingContext(ctx)
+		if err != nil || !isPlaying {
+			return err
+		}
 	}
 
 	reqURL := u.baseURL + "me/player/pause"
-	ok = u.sendRequest(http.MethodPut, reqURL) && ok
-
-	return ok
+	return u.sendRequest(ctx, http.MethodPut, reqURL)
 }
 
 // Continue playback for a User (i.e. press play)
-func (u *User) Play() bool {
-	isPlaying, ok := u.IsPlaying()
-	if isPlaying {
-		return true
+func (u *User) Play() error {
+	return u.PlayContext(context.Background())
+}
+
+// PlayContext is Play with a context
+func (u *User) PlayContext(ctx context.Context) (err error) {
+	ctx, span := u.engine.startCall(ctx, "Play")
+	defer span.end(&err)
+
+	if err := u.checkScopes("Play"); err != nil {
+		return err
+	}
+	// Skip the command if playback is already playing, when the User
+	// granted a scope to read it
+	if u.checkScopes("IsPlaying") == nil {
+		isPlaying, err := u.IsPlayingContext(ctx)
+		if err != nil || isPlaying {
+			return err
+		}
 	}
 
 	reqURL := u.baseURL + "me/player/play"
-	ok = u.sendRequest(http.MethodPut, reqURL) && ok
-
-	return ok
+	return u.sendRequest(ctx, http.MethodPut, reqURL)
 }
 
 // Start playing a specific Track
-func (u *User) PlayTrack(q Query, i interface{}) bool {
-	uri := ""
-	ok := true
-
-	switch v := i.(type) {
-	case string:
-		bytes, searchErr := q.search(v, "track")
-		if searchErr != nil {
-			ok = false
-		}
+func (u *User) PlayTrack(q Query, i interface{}) error {
+	return u.PlayTrackContext(context.Background(), q, i)
+}
 
-		searchResult := searchResult{}
-		jsonErr := json.Unmarshal(bytes, &searchResult)
+// PlayTrackContext is PlayTrack with a context
+func (u *User) PlayTrackContext(ctx context.Context, q Query, i interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "PlayTrack")
+	defer span.end(&err)
 
-		if jsonErr != nil {
-			log.Fatal("JSON Error:", jsonErr)
-		}
-
-		if searchResult.Tracks.Total > 0 {
-			track := searchResult.Tracks.Items[0]
-			uri = track.ID
-		}
-	case Track:
-		uri = v.ID
-	// Invalid Type
-	default:
-		return false
+	if err := u.checkScopes("PlayTrack"); err != nil {
+		return err
+	}
+	uri, err := q.trackID(ctx, i)
+	if err != nil {
+		return err
 	}
 
 	// Source: https://github.com/zmb3/spotify/
@@ -97,32 +92,27 @@ func (u *User) PlayTrack(q Query, i interface{}) bool {
 		Uris []string `json:"uris"`
 		// Play     bool     `json:"play"`
 	}{
-		Uris: []string{"spotify:track:" + uri},
+		Uris: []string{uriOf(EntityTrack, uri)},
 	}
-	buf := new(bytes.Buffer)
-	err := json.NewEncoder(buf).Encode(reqData)
 	/// End Source
 
-	if err != nil {
-		return false
-	}
-
-	req, err := http.NewRequest(http.MethodPut, u.baseURL+"me/player/play", buf)
-
-	if err != nil {
-		return false
-	}
-	err = u.execute(req, nil, http.StatusNoContent)
-	if err != nil {
-		return false
-	}
-
-	return ok
+	return u.engine.do(ctx, http.MethodPut, u.baseURL+"me/player/play", reqData, nil)
 }
 
 // Set User's Spotify volume
 // vol = the volume to set (should be a value from 0 to 100 inclusive)
-func (u *User) SetVolume(vol int) bool {
+func (u *User) SetVolume(vol int) error {
+	return u.SetVolumeContext(context.Background(), vol)
+}
+
+// SetVolumeContext is SetVolume with a context
+func (u *User) SetVolumeContext(ctx context.Context, vol int) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SetVolume")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SetVolume"); err != nil {
+		return err
+	}
 	if vol > 100 {
 		vol = 100
 	} else if vol < 0 {
@@ -131,14 +121,24 @@ func (u *User) SetVolume(vol int) bool {
 
 	reqURL := u.baseURL + "me/player/volume?volume_percent=" + fmt.Sprint(vol)
 
-	ok := u.sendRequest(http.MethodPut, reqURL)
-	return ok
+	return u.sendRequest(ctx, http.MethodPut, reqURL)
 }
 
 // Set User's repeat mode
 // On boolean determines if repeat mode should be on or off
 // Track boolean repeats track if true, repeats context (album, playlist, etc) if false
-func (u *User) SetRepeat(on bool, track bool) bool {
+func (u *User) SetRepeat(on bool, track bool) error {
+	return u.SetRepeatContext(context.Background(), on, track)
+}
+
+// SetRepeatContext is SetRepeat with a context
+func (u *User) SetRepeatContext(ctx context.Context, on bool, track bool) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SetRepeat")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SetRepeat"); err != nil {
+		return err
+	}
 	state := ""
 	if !on {
 		state = "off"
@@ -150,12 +150,22 @@ func (u *User) SetRepeat(on bool, track bool) bool {
 
 	reqURL := u.baseURL + "me/player/repeat?state=" + state
 
-	ok := u.sendRequest(http.MethodPut, reqURL)
-	return ok
+	return u.sendRequest(ctx, http.MethodPut, reqURL)
 }
 
 // Set shuffle mode for User
-func (u *User) SetShuffle(on bool) bool {
+func (u *User) SetShuffle(on bool) error {
+	return u.SetShuffleContext(context.Background(), on)
+}
+
+// SetShuffleContext is SetShuffle with a context
+func (u *User) SetShuffleContext(ctx context.Context, on bool) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SetShuffle")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SetShuffle"); err != nil {
+		return err
+	}
 	state := ""
 	if on {
 		state = "true"
@@ -165,80 +175,110 @@ func (u *User) SetShuffle(on bool) bool {
 
 	reqURL := u.baseURL + "me/player/shuffle?state=" + state
 
-	ok := u.sendRequest(http.MethodPut, reqURL)
-	return ok
+	return u.sendRequest(ctx, http.MethodPut, reqURL)
 }
 
 // Skip forward 1 track
-func (u *User) SkipToNext() bool {
+func (u *User) SkipToNext() error {
+	return u.SkipToNextContext(context.Background())
+}
+
+// SkipToNextContext is SkipToNext with a context
+func (u *User) SkipToNextContext(ctx context.Context) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SkipToNext")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SkipToNext"); err != nil {
+		return err
+	}
 	reqURL := u.baseURL + "me/player/next"
 
-	ok := u.sendRequest(http.MethodPost, reqURL)
-	return ok
+	return u.sendRequest(ctx, http.MethodPost, reqURL)
 }
 
 // Skip backwards 1 track
-func (u *User) SkipToPrev() bool {
+func (u *User) SkipToPrev() error {
+	return u.SkipToPrevContext(context.Background())
+}
+
+// SkipToPrevContext is SkipToPrev with a context
+func (u *User) SkipToPrevContext(ctx context.Context) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SkipToPrev")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SkipToPrev"); err != nil {
+		return err
+	}
 	reqURL := u.baseURL + "me/player/previous"
 
-	ok := u.sendRequest(http.MethodPost, reqURL)
-	return ok
+	return u.sendRequest(ctx, http.MethodPost, reqURL)
 }
 
 // Add track to User's Queue
-func (u *User) AddTrackToQueue(q Query, i interface{}) bool {
-	uri := ""
-	ok := true
-
-	switch v := i.(type) {
-	case string:
-		bytes, searchErr := q.search(v, "track")
-		if searchErr != nil {
-			ok = false
-		}
+func (u *User) AddTrackToQueue(q Query, i interface{}) error {
+	return u.AddTrackToQueueContext(context.Background(), q, i)
+}
 
-		searchResult := searchResult{}
-		jsonErr := json.Unmarshal(bytes, &searchResult)
+// AddTrackToQueueContext is AddTrackToQueue with a context
+func (u *User) AddTrackToQueueContext(ctx context.Context, q Query, i interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "AddTrackToQueue")
+	defer span.end(&err)
 
-		if jsonErr != nil {
-			log.Fatal("JSON Error:", jsonErr)
-		}
-
-		if searchResult.Tracks.Total > 0 {
-			track := searchResult.Tracks.Items[0]
-			uri = track.ID
-		}
-	case Track:
-		uri = v.ID
-	// Invalid Type
-	default:
-		return false
+	if err := u.checkScopes("AddTrackToQueue"); err != nil {
+		return err
+	}
+	uri, err := q.trackID(ctx, i)
+	if err != nil {
+		return err
 	}
 
-	reqURL := u.baseURL + "me/player/queue?uri=spotify:track:" + uri
+	reqURL := u.baseURL + "me/player/queue?uri=" + url.QueryEscape(uriOf(EntityTrack, uri))
 
-	ok = u.sendRequest(http.MethodPost, reqURL) && ok
-	return ok
+	return u.sendRequest(ctx, http.MethodPost, reqURL)
 }
 
 // Move to specific position in a song (by seconds)
-func (u *User) SeekToPosition(seconds float64) bool {
-	ms := seconds * 1000
+func (u *User) SeekToPosition(seconds float64) error {
+	return u.SeekToPositionContext(context.Background(), seconds)
+}
+
+// SeekToPositionContext is SeekToPosition with a context
+func (u *User) SeekToPositionContext(ctx context.Context, seconds float64) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SeekToPosition")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SeekToPosition"); err != nil {
+		return err
+	}
+	if seconds < 0 {
+		return invalidInput("negative seek position %v", seconds)
+	}
+	ms := int64(seconds * 1000)
 
 	reqURL := u.baseURL + "me/player/seek?position_ms=" + fmt.Sprint(ms)
 
-	ok := u.sendRequest(http.MethodPut, reqURL)
-	return ok
+	return u.sendRequest(ctx, http.MethodPut, reqURL)
 }
 
 // Return User's currently playing track
-func (u *User) GetCurrentlyPlayingTrack() (Track, bool) {
+func (u *User) GetCurrentlyPlayingTrack() (Track, error) {
+	return u.GetCurrentlyPlayingTrackContext(context.Background())
+}
+
+// GetCurrentlyPlayingTrackContext is GetCurrentlyPlayingTrack with a context
+func (u *User) GetCurrentlyPlayingTrackContext(ctx context.Context) (_ Track, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetCurrentlyPlayingTrack")
+	defer func() { span.endItems(err, 1) }()
+
+	if err := u.checkScopes("GetCurrentlyPlayingTrack"); err != nil {
+		return Track{}, err
+	}
 	reqURL := u.baseURL + "me/player/currently-playing"
 	track := currentlyPlaying{}
 
-	ok := u.sendGetRequest(reqURL, &track)
+	err = u.sendGetRequest(ctx, reqURL, &track)
 
-	return track.Track, ok
+	return track.Track, err
 }
 
 // Currently Playing struct- maps to Spotify JSON response format by tag `json: "var_name"`
@@ -267,14 +307,14 @@ type currentlyPlaying struct {
 }
 
 // Return User's playback state
-func (u *User) getPlaybackState() (playbackState, bool) {
+func (u *User) getPlaybackState(ctx context.Context) (playbackState, error) {
 
 	reqURL := u.baseURL + "me/player/currently-playing"
 	pb := playbackState{}
 
-	ok := u.sendGetRequest(reqURL, &pb)
+	err := u.sendGetRequest(ctx, reqURL, &pb)
 
-	return pb, ok
+	return pb, err
 }
 
 // Playback State struct- maps to Spotify JSON response format by tag `json: "var_name"`
@@ -306,31 +346,86 @@ type playbackState struct {
 }
 
 // Return whether a user is in shuffle mode or not
-func (u *User) IsShuffling() (bool, bool) {
-	pb, ok := u.getPlaybackState()
-	return pb.ShuffleState, ok
+func (u *User) IsShuffling() (bool, error) {
+	return u.IsShufflingContext(context.Background())
+}
+
+// IsShufflingContext is IsShuffling with a context
+func (u *User) IsShufflingContext(ctx context.Context) (_ bool, err error) {
+	ctx, span := u.engine.startCall(ctx, "IsShuffling")
+	defer span.end(&err)
+
+	if err := u.checkScopes("IsShuffling"); err != nil {
+		return false, err
+	}
+	pb, err := u.getPlaybackState(ctx)
+	return pb.ShuffleState, err
 }
 
 // Return whether a user has music playing or not
-func (u *User) IsPlaying() (bool, bool) {
-	pb, ok := u.getPlaybackState()
-	return pb.IsPlaying, ok
+func (u *User) IsPlaying() (bool, error) {
+	return u.IsPlayingContext(context.Background())
+}
+
+// IsPlayingContext is IsPlaying with a context
+func (u *User) IsPlayingContext(ctx context.Context) (_ bool, err error) {
+	ctx, span := u.engine.startCall(ctx, "IsPlaying")
+	defer span.end(&err)
+
+	if err := u.checkScopes("IsPlaying"); err != nil {
+		return false, err
+	}
+	pb, err := u.getPlaybackState(ctx)
+	return pb.IsPlaying, err
 }
 
 // Return User's current location in their currently playing track in seconds
-func (u *User) CurrentTrackProgress() (float64, bool) {
-	pb, ok := u.getPlaybackState()
-	return float64(pb.ProgressMs / 1000), ok
+func (u *User) CurrentTrackProgress() (float64, error) {
+	return u.CurrentTrackProgressContext(context.Background())
+}
+
+// CurrentTrackProgressContext is CurrentTrackProgress with a context
+func (u *User) CurrentTrackProgressContext(ctx context.Context) (_ float64, err error) {
+	ctx, span := u.engine.startCall(ctx, "CurrentTrackProgress")
+	defer span.end(&err)
+
+	if err := u.checkScopes("CurrentTrackProgress"); err != nil {
+		return 0, err
+	}
+	pb, err := u.getPlaybackState(ctx)
+	return float64(pb.ProgressMs / 1000), err
 }
 
 // Return User's active Device
-func (u *User) ActiveDevice() (Device, bool) {
-	pb, ok := u.getPlaybackState()
-	return pb.Device, ok
+func (u *User) ActiveDevice() (Device, error) {
+	return u.ActiveDeviceContext(context.Background())
+}
+
+// ActiveDeviceContext is ActiveDevice with a context
+func (u *User) ActiveDeviceContext(ctx context.Context) (_ Device, err error) {
+	ctx, span := u.engine.startCall(ctx, "ActiveDevice")
+	defer func() { span.endItems(err, 1) }()
+
+	if err := u.checkScopes("ActiveDevice"); err != nil {
+		return Device{}, err
+	}
+	pb, err := u.getPlaybackState(ctx)
+	return pb.Device, err
 }
 
 // Return User's current repeat state
-func (u *User) CurrentRepeatState() (string, bool) {
-	pb, ok := u.getPlaybackState()
-	return pb.RepeatState, ok
+func (u *User) CurrentRepeatState() (string, error) {
+	return u.CurrentRepeatStateContext(context.Background())
+}
+
+// CurrentRepeatStateContext is CurrentRepeatState with a context
+func (u *User) CurrentRepeatStateContext(ctx context.Context) (_ string, err error) {
+	ctx, span := u.engine.startCall(ctx, "CurrentRepeatState")
+	defer span.end(&err)
+
+	if err := u.checkScopes("CurrentRepeatState"); err != nil {
+		return "", err
+	}
+	pb, err := u.getPlaybackState(ctx)
+	return pb.RepeatState, err
 }
@@ -1,6 +1,7 @@
 package spotigo
 
 import (
+	"context"
 	"time"
 )
 
@@ -75,7 +76,7 @@ func (p *Playlist) GetName() string {
 
 // Get Playlist URI
 func (p *Playlist) GetURI() string {
-	return p.ID
+	return uriOf(EntityPlaylist, p.ID)
 }
 
 // Get number of Playlist followers
@@ -111,27 +112,37 @@ type tracksOfPlaylist struct {
 }
 
 // Get all Track URIs for Playlist
-func (p *Playlist) GetTrackURIs(u User) []string {
+func (p *Playlist) GetTrackURIs(u User) ([]string, error) {
+	return p.GetTrackURIsContext(context.Background(), u)
+}
+
+// GetTrackURIsContext is GetTrackURIs with a context
+func (p *Playlist) GetTrackURIsContext(ctx context.Context, u User) (items []string, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetTrackURIs")
+	defer func() { span.endItems(err, len(items)) }()
+
 	uris := make([]string, 0)
 	for _, track := range p.Tracks.Items {
-		uris = append(uris, track.Track.ID)
+		uris = append(uris, uriOf(EntityTrack, track.Track.ID))
 	}
 
 	// * Allows call to get tracks of playlist when there are > 100 songs
 	next := p.Tracks.Next
 	for next != "" {
 		tracks := tracksOfPlaylist{}
-		u.sendGetRequest(next, &tracks)
+		if err := u.sendGetRequest(ctx, next, &tracks); err != nil {
+			return uris, err
+		}
 
 		for _, track := range tracks.Items {
-			uris = append(uris, track.Track.ID)
+			uris = append(uris, uriOf(EntityTrack, track.Track.ID))
 		}
 
 		next = tracks.Next
 
 	}
 
-	return uris
+	return uris, nil
 }
 
 // Get all Artist URIs for Playlist
@@ -139,14 +150,22 @@ func (p *Playlist) GetArtistURIs() []string {
 	uris := make([]string, 0)
 	for _, x := range p.Tracks.Items {
 		for _, artist := range x.Track.Artists {
-			uris = append(uris, artist.ID)
+			uris = append(uris, uriOf(EntityArtist, artist.ID))
 		}
 	}
 	return uris
 }
 
 // Get all Tracks on a Playlist
-func (p *Playlist) GetTracks(u User) []Track {
+func (p *Playlist) GetTracks(u User) ([]Track, error) {
+	return p.GetTracksContext(context.Background(), u)
+}
+
+// GetTracksContext is GetTracks with a context
+func (p *Playlist) GetTracksContext(ctx context.Context, u User) (items []Track, err error) {
+	ctx, span := u.engine.startCall(ctx, "GetTracks")
+	defer func() { span.endItems(err, len(items)) }()
+
 	uris := make([]Track, 0)
 	for _, x := range p.Tracks.Items {
 		uris = append(uris, x.Track)
@@ -156,7 +175,9 @@ func (p *Playlist) GetTracks(u User) []Track {
 	next := p.Tracks.Next
 	for next != "" {
 		tracks := tracksOfPlaylist{}
-		u.sendGetRequest(next, &tracks)
+		if err := u.sendGetRequest(ctx, next, &tracks); err != nil {
+			return uris, err
+		}
 
 		for _, track := range tracks.Items {
 			uris = append(uris, track.Track)
@@ -166,5 +187,5 @@ func (p *Playlist) GetTracks(u User) []Track {
 
 	}
 
-	return uris
+	return uris, nil
 }
@@ -0,0 +1,160 @@
+package spotigo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// AuthPrompt shows the user the authorization URL and returns what they paste
+// back: either the whole URL they were redirected to, or just its code
+// Used with WithAuthPrompt on machines without a browser, e.g. over SSH or in
+// a container
+// ctx is done when the login is cancelled or times out; the prompt should
+// then return
+type AuthPrompt func(ctx context.Context, authURL string) (string, error)
+
+// Log in by asking the user to open the authorization URL themselves, instead
+// of opening a browser and listening for the redirect
+// The redirect page doesn't need to load; its URL is copied from the browser's
+// address bar and pasted back to prompt
+func WithAuthPrompt(prompt AuthPrompt) Option {
+	return func(o *options) {
+		o.authPrompt = prompt
+	}
+}
+
+// ConsolePrompt prints the authorization URL to w and reads the pasted
+// redirect URL or code as one line from r
+// Reads can't be interrupted, so lines are read by one goroutine per
+// ConsolePrompt; a cancelled prompt returns at once and a line typed after it
+// goes to the next prompt
+//
+//	spotigo.WithAuthPrompt(spotigo.ConsolePrompt(os.Stdout, os.Stdin))
+func ConsolePrompt(w io.Writer, r io.Reader) AuthPrompt {
+	type line struct {
+		text string
+		err  error
+	}
+	var (
+		start sync.Once
+		// Each send asks the reading goroutine for a line
+		want  = make(chan struct{})
+		lines = make(chan line)
+
+		mu sync.Mutex
+		// Whether a line was asked for but not received, by a cancelled prompt
+		pending bool
+	)
+	read := func() {
+		in := bufio.NewReader(r)
+		for range want {
+			text, err := in.ReadString('\n')
+			if err != nil && (err != io.EOF || text == "") {
+				lines <- line{err: err}
+				continue
+			}
+			lines <- line{text: strings.TrimSpace(text)}
+		}
+	}
+
+	return func(ctx context.Context, authURL string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		start.Do(func() { go read() })
+
+		fmt.Fprintf(w, "Log in to Spotify by opening this URL in a browser:\n\n%s\n\n", authURL)
+		fmt.Fprint(w, "Then paste the URL you were redirected to (or its code): ")
+		if !pending {
+			select {
+			case want <- struct{}{}:
+				pending = true
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		select {
+		case l := <-lines:
+			pending = false
+			return l.text, l.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// Log in through prompt and exchange the pasted redirect URL or code for a token
+// The state is checked when a redirect URL is pasted; a bare code has no state
+// The prompt's context is cancelled if nothing is pasted within timeout
+func (a authenticator) promptLogin(ctx context.Context, prompt AuthPrompt, state string, timeout time.Duration, authOpts, exchangeOpts []oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	type result struct {
+		input string
+		err   error
+	}
+	done := make(chan result, 1)
+	// done is buffered, so the prompt can finish after a cancelled login
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		input, err := prompt(ctx, a.authURL(state, authOpts...))
+		done <- result{input, err}
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var res result
+	select {
+	case res = <-done:
+	case <-expired:
+		return nil, ErrLoginTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	values, err := pastedValues(res.input, state)
+	if err != nil {
+		return nil, err
+	}
+	return a.exchange(ctx, state, values, exchangeOpts...)
+}
+
+// Query parameters of a pasted redirect URL, or the parameters a redirect
+// carrying a bare code would have had
+func pastedValues(input, state string) (url.Values, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, invalidInput("no redirect URL or code given")
+	}
+	if !strings.ContainsAny(input, "?=&") {
+		return url.Values{"code": {input}, "state": {state}}, nil
+	}
+
+	// Accept the whole URL, or just its query string
+	query := input
+	if i := strings.Index(input, "?"); i >= 0 {
+		query = input[i+1:]
+	}
+	if i := strings.Index(query, "#"); i >= 0 {
+		query = query[:i]
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, invalidInput("couldn't parse redirect URL: %v", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,131 @@
+package spotigo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+)
+
+func TestAuthPromptPastedValues(t *testing.T) {
+	tests := []struct {
+		name string
+		// Turn the URL the login redirected to into what the user pastes
+		paste   func(redirect *url.URL) string
+		wantErr bool
+	}{
+		{"full URL", func(r *url.URL) string { return r.String() }, false},
+		{"surrounding space", func(r *url.URL) string { return "  " + r.String() + "\n" }, false},
+		{"URL with a fragment", func(r *url.URL) string { return r.String() + "#_=_" }, false},
+		{"query string", func(r *url.URL) string { return r.RawQuery }, false},
+		{"query string with ?", func(r *url.URL) string { return "?" + r.RawQuery }, false},
+		{"bare code", func(r *url.URL) string { return r.Query().Get("code") }, false},
+		{"state mismatch", func(r *url.URL) string { return "code=" + r.Query().Get("code") + "&state=forged" }, true},
+		{"URL without state", func(r *url.URL) string { return "http://localhost:8080/callback?code=" + r.Query().Get("code") }, true},
+		{"auth error", func(*url.URL) string { return "http://localhost:8080/callback?error=access_denied" }, true},
+		{"empty", func(*url.URL) string { return " " }, true},
+	}
+	for _, tt := range tests {
+		srv := newServer(t)
+		redirect := approve(srv)
+		prompt := func(ctx context.Context, authURL string) (string, error) {
+			loc, err := redirect(ctx, authURL)
+			if err != nil {
+				return "", err
+			}
+			u, err := url.Parse(loc)
+			if err != nil {
+				return "", err
+			}
+			return tt.paste(u), nil
+		}
+
+		u, err := spotigo.NewUserPKCE("id", testOptions(srv, spotigo.WithAuthPrompt(prompt))...)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if _, err := u.Token(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestAuthPromptEmptyIsInvalidInput(t *testing.T) {
+	srv := newServer(t)
+	prompt := func(context.Context, string) (string, error) { return "", nil }
+	_, err := spotigo.NewUserPKCE("id", testOptions(srv, spotigo.WithAuthPrompt(prompt))...)
+	if !errors.Is(err, spotigo.ErrInvalidInput) {
+		t.Errorf("got %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestAuthPromptTimeout(t *testing.T) {
+	srv := newServer(t)
+	cancelled := make(chan struct{})
+	prompt := func(ctx context.Context, authURL string) (string, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return "", ctx.Err()
+	}
+
+	_, err := spotigo.NewUserPKCE("id", testOptions(srv,
+		spotigo.WithAuthPrompt(prompt), spotigo.WithAuthTimeout(20*time.Millisecond))...)
+	if !errors.Is(err, spotigo.ErrLoginTimeout) {
+		t.Fatalf("got %v, want ErrLoginTimeout", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Error("the prompt's context wasn't cancelled")
+	}
+}
+
+func TestConsolePromptCancel(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	var out bytes.Buffer
+	prompt := spotigo.ConsolePrompt(&out, r)
+
+	// A cancelled prompt returns without a line being typed
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := prompt(ctx, "https://accounts.example/authorize")
+		errc <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled prompt didn't return")
+	}
+	if !strings.Contains(out.String(), "https://accounts.example/authorize") {
+		t.Errorf("output lacks the URL:\n%s", out.String())
+	}
+
+	// The line typed after the cancellation goes to the next prompt
+	go w.Write([]byte("  the-code \n"))
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got, err := prompt(ctx, "https://accounts.example/authorize")
+	if err != nil || got != "the-code" {
+		t.Errorf("got %q, %v, want the-code", got, err)
+	}
+}
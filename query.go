@@ -1,402 +1,372 @@
 package spotigo
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 )
 
 // Query struct- stores developer's client and secret IDs
-// and their API access token
+// and the source of their API access token
 type Query struct {
 	client string
 	secret string
-	token  string `json:"access_token"`
-}
-
-// Constructor- create a new Query
-func NewQuery(client string, secret string) (Query, error) {
-	q := Query{client: client, secret: secret}
+	tokens *clientCredentials
 
-	token, authErr := getToken(client, secret)
-	q.token = token
+	baseURL string
+	engine  *engine
 
-	return q, authErr
+	// Minimum confidence for name lookups; 0 accepts any match
+	matchThreshold float64
+	// Most names resolved at once by the Get*ByNames methods
+	parallelism int
 }
 
-// Query Methods
+// Constructor- create a new Query
+// Options may override the API and Accounts base URLs and the HTTP client
+func NewQuery(client string, secret string, opts ...Option) (Query, error) {
+	return NewQueryContext(context.Background(), client, secret, opts...)
+}
 
-// Get Album by URI
-func (q Query) GetAlbumByURI(uri string) (Album, bool) {
-	bytes, fetchErr := fetch(q.token, uri, "albums")
-	album := Album{}
-	ok := true
-	if fetchErr != nil {
-		ok = false
-		fmt.Println("Fetch Error:", fetchErr)
+// NewQueryContext is NewQuery with a context
+func NewQueryContext(ctx context.Context, client string, secret string, opts ...Option) (Query, error) {
+	o := newOptions(opts)
+	o.shareRateLimiter(client)
+	httpClient := o.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
 	}
+	q := Query{client: client, secret: secret, baseURL: o.apiURL, engine: newEngine(httpClient, o), matchThreshold: o.matchThreshold, parallelism: o.parallelism}
 
-	jsonErr := json.Unmarshal(bytes, &album)
-
-	if jsonErr != nil {
-		ok = false
-		fmt.Println("JSON Error:", jsonErr)
+	q.tokens = &clientCredentials{
+		httpClient:  httpClient,
+		accountsURL: o.accountsURL,
+		client:      client,
+		secret:      secret,
 	}
 
-	return album, ok
-}
-
-// Get Artist by URI
-func (q Query) GetArtistByURI(uri string) (Artist, bool) {
-	bytes, fetchErr := fetch(q.token, uri, "artists")
-	artist := Artist{}
-	ok := true
-	if fetchErr != nil {
-		ok = false
-		fmt.Println("Fetch Error:", fetchErr)
+	q.engine.token = q.tokens.get
+	q.engine.invalidate = q.tokens.invalidate
+	q.engine.identity = func(context.Context) (string, error) {
+		return "app " + client, nil
 	}
 
-	jsonErr := json.Unmarshal(bytes, &artist)
+	// Fetch the first token now so bad credentials are reported here
+	_, authErr := q.tokens.get(ctx)
 
-	if jsonErr != nil {
-		ok = false
-		fmt.Println("JSON Error:", jsonErr)
-	}
-
-	return artist, ok
+	return q, authErr
 }
 
-// Get Track by URI
-func (q Query) GetTrackByURI(uri string) (Track, bool) {
-	bytes, fetchErr := fetch(q.token, uri, "tracks")
-	track := Track{}
-	ok := true
-	if fetchErr != nil {
-		ok = false
-		fmt.Println("Fetch Error:", fetchErr)
-	}
-
-	jsonErr := json.Unmarshal(bytes, &track)
-
-	if jsonErr != nil {
-		ok = false
-		fmt.Println("JSON Error:", jsonErr)
-	}
+// Query Methods
 
-	return track, ok
+// Get Album by ID, URI or open.spotify.com URL
+func (q Query) GetAlbumByURI(uri string) (Album, error) {
+	return q.GetAlbumByURIContext(context.Background(), uri)
 }
 
-// Get Playlist by URI
-func (q Query) GetPlaylistByURI(uri string) (Playlist, bool) {
-	bytes, fetchErr := fetch(q.token, uri, "playlists")
-	playlist := Playlist{}
-	ok := true
-	if fetchErr != nil {
-		ok = false
-		fmt.Println("Fetch Error:", fetchErr)
-	}
+// GetAlbumByURIContext is GetAlbumByURI with a context
+func (q Query) GetAlbumByURIContext(ctx context.Context, uri string) (_ Album, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetAlbumByURI")
+	defer func() { span.endItems(err, 1) }()
 
-	jsonErr := json.Unmarshal(bytes, &playlist)
-
-	if jsonErr != nil {
-		ok = false
-		fmt.Println("JSON Error:", jsonErr)
-	}
+	album := Album{}
+	err = q.fetch(ctx, uri, EntityAlbum, &album)
+	return album, err
+}
 
-	return playlist, ok
+// Get Artist by ID, URI or open.spotify.com URL
+func (q Query) GetArtistByURI(uri string) (Artist, error) {
+	return q.GetArtistByURIContext(context.Background(), uri)
 }
 
-// General search function- searches Spotify platform and returns
-// the first result that corresponds to returnType
-// Possible returnTypes: "album","artist","playlist","track"
-// The input parameter is a string search query; for the user to reliably get the
-// track they're looking for, they need to include as much information in this
-// string as possible. Misspelled or incomplete inputs will return a result,
-// but the more information included, the more likely the result will be as intended
-// Bad input example: "disco"
-// Good input example: "Disco Man Remi Wolf"
-func (q Query) search(input string, returnType string) ([]byte, error) {
-	var err error
-	reqClient := &http.Client{}
-	baseURL := "https://api.spotify.com/v1/"
+// GetArtistByURIContext is GetArtistByURI with a context
+func (q Query) GetArtistByURIContext(ctx context.Context, uri string) (_ Artist, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetArtistByURI")
+	defer func() { span.endItems(err, 1) }()
 
-	input = url.QueryEscape(input)
+	artist := Artist{}
+	err = q.fetch(ctx, uri, EntityArtist, &artist)
+	return artist, err
+}
 
-	req, httpErr := http.NewRequest("GET", (baseURL + "search" + "?q=" + input + "&type=" + returnType), nil)
-	req.Header.Add("Authorization", ("Bearer " + q.token))
-	req.Header.Add("Content-Type", "application/json")
+// Get Track by ID, URI or open.spotify.com URL
+func (q Query) GetTrackByURI(uri string) (Track, error) {
+	return q.GetTrackByURIContext(context.Background(), uri)
+}
 
-	if httpErr != nil {
-		err = httpErr
-	}
+// GetTrackByURIContext is GetTrackByURI with a context
+func (q Query) GetTrackByURIContext(ctx context.Context, uri string) (_ Track, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetTrackByURI")
+	defer func() { span.endItems(err, 1) }()
 
-	res, fetchErr := reqClient.Do(req)
-	if fetchErr != nil {
-		err = fetchErr
-	}
+	track := Track{}
+	err = q.fetch(ctx, uri, EntityTrack, &track)
+	return track, err
+}
 
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		err = httpErr
-	}
+// Get Playlist by ID, URI or open.spotify.com URL
+func (q Query) GetPlaylistByURI(uri string) (Playlist, error) {
+	return q.GetPlaylistByURIContext(context.Background(), uri)
+}
 
-	defer res.Body.Close()
+// GetPlaylistByURIContext is GetPlaylistByURI with a context
+func (q Query) GetPlaylistByURIContext(ctx context.Context, uri string) (_ Playlist, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetPlaylistByURI")
+	defer func() { span.endItems(err, 1) }()
 
-	if res.StatusCode != 200 {
-		err = errors.New("Status code: " + fmt.Sprint(res.StatusCode))
-	}
-	return body, err
+	playlist := Playlist{}
+	err = q.fetch(ctx, uri, EntityPlaylist, &playlist)
+	return playlist, err
 }
 
 // Get Artist by name
-// input is a string search query as described in the search function
-func (q Query) GetArtistByName(input string) (Artist, bool) {
-	bytes, searchErr := q.search(input, "artist")
-
-	searchResult := searchResult{}
-	ok := true
-	if searchErr != nil {
-		ok = false
-		fmt.Println("Search Error:", searchErr)
-	}
-
-	jsonErr := json.Unmarshal(bytes, &searchResult)
+// input is a string search query as described in Search
+// The best of several results is returned (see MatchArtist); with
+// WithMatchThreshold, a match below the threshold fails with ErrLowConfidence
+func (q Query) GetArtistByName(input string) (Artist, error) {
+	return q.GetArtistByNameContext(context.Background(), input)
+}
 
-	if jsonErr != nil {
-		ok = false
-		fmt.Println("JSON Error:", jsonErr)
-	}
+// GetArtistByNameContext is GetArtistByName with a context
+func (q Query) GetArtistByNameContext(ctx context.Context, input string) (_ Artist, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetArtistByName")
+	defer func() { span.endItems(err, 1) }()
 
-	if searchResult.Artists.Total > 0 {
-		artist := searchResult.Artists.Items[0]
-		return artist, ok
-	}
-
-	// No Results Found
-	return Artist{}, false
+	m, err := q.MatchArtistContext(ctx, input)
+	return m.Artist, err
 }
 
 // Get Album by name
-// input is a string search query as described in the search function
-func (q Query) GetAlbumByName(input string) (Album, bool) {
-	bytes, searchErr := q.search(input, "album")
-
-	searchResult := searchResult{}
-	ok := true
-	if searchErr != nil {
-		ok = false
-		fmt.Println("Search Error:", searchErr)
-	}
+// input is a string search query as described in Search
+// The best of several results is returned (see MatchAlbum); with
+// WithMatchThreshold, a match below the threshold fails with ErrLowConfidence
+func (q Query) GetAlbumByName(input string) (Album, error) {
+	return q.GetAlbumByNameContext(context.Background(), input)
+}
 
-	jsonErr := json.Unmarshal(bytes, &searchResult)
+// GetAlbumByNameContext is GetAlbumByName with a context
+func (q Query) GetAlbumByNameContext(ctx context.Context, input string) (_ Album, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetAlbumByName")
+	defer func() { span.endItems(err, 1) }()
 
-	if jsonErr != nil {
-		ok = false
-		fmt.Println("JSON Error:", jsonErr)
-	}
+	m, err := q.MatchAlbumContext(ctx, input)
+	return m.Album, err
+}
 
-	if searchResult.Albums.Total > 0 {
-		album := searchResult.Albums.Items[0]
-		return album, ok
-	}
+// Get Track by name
+// input is a string search query as described in Search
+// The best of several results is returned (see MatchTrack); with
+// WithMatchThreshold, a match below the threshold fails with ErrLowConfidence
+func (q Query) GetTrackByName(input string) (Track, error) {
+	return q.GetTrackByNameContext(context.Background(), input)
+}
 
-	// No Results Found
-	return Album{}, false
+// GetTrackByNameContext is GetTrackByName with a context
+func (q Query) GetTrackByNameContext(ctx context.Context, input string) (_ Track, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetTrackByName")
+	defer func() { span.endItems(err, 1) }()
+
+	m, err := q.MatchTrackContext(ctx, input)
+	return m.Track, err
 }
 
-// Get Track by name
-// input is a string search query as described in the search function
-func (q Query) GetTrackByName(input string) (Track, bool) {
-	bytes, searchErr := q.search(input, "track")
+// Get Playlist by name
+// input is a string search query as described in Search
+// The best of several results is returned (see MatchPlaylist); with
+// WithMatchThreshold, a match below the threshold fails with ErrLowConfidence
+func (q Query) GetPlaylistByName(input string) (Playlist, error) {
+	return q.GetPlaylistByNameContext(context.Background(), input)
+}
 
-	searchResult := searchResult{}
-	ok := true
+// GetPlaylistByNameContext is GetPlaylistByName with a context
+func (q Query) GetPlaylistByNameContext(ctx context.Context, input string) (_ Playlist, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetPlaylistByName")
+	defer func() { span.endItems(err, 1) }()
 
-	if searchErr != nil {
-		ok = false
-		fmt.Println("Search Error:", searchErr)
-	}
+	m, err := q.MatchPlaylistContext(ctx, input)
+	return m.Playlist, err
+}
 
-	jsonErr := json.Unmarshal(bytes, &searchResult)
+// Error for a search that had no results
+func notFound(returnType string, input string) error {
+	return fmt.Errorf("%w: no %s matching %q", ErrNotFound, returnType, input)
+}
 
-	if jsonErr != nil {
-		ok = false
-		fmt.Println("JSON Error:", jsonErr)
+// Fetch an item of type typ by ID, URI or URL and decode it into result
+func (q Query) fetch(ctx context.Context, uri string, typ EntityType, result interface{}) error {
+	if uri == "" {
+		return invalidInput("empty URI")
 	}
-
-	if searchResult.Tracks.Total > 0 {
-		track := searchResult.Tracks.Items[0]
-		return track, ok
+	id, err := parseIDOf(uri, typ)
+	if err != nil {
+		return err
 	}
+	return q.get(ctx, q.baseURL+string(typ)+"s/"+url.PathEscape(id), result)
+}
 
-	// No Results Found
-	return Track{}, false
+// Execute HTTP GET request and decode the JSON response into result
+func (q Query) get(ctx context.Context, reqURL string, result interface{}) error {
+	return q.engine.get(ctx, reqURL, result)
 }
 
-// Get Playlist by name
-// input is a string search query as described in the search function
-func (q Query) GetPlaylistByName(input string) (Playlist, bool) {
-	bytes, searchErr := q.search(input, "playlist")
+// Get multiple Tracks by IDs, URIs or URLs, fetching up to 50 per request
+// Tracks are returned in the order of uris
+// On failure the error is a *BatchError and failed Tracks are left empty; Tracks
+// that don't exist fail with ErrNotFound
+func (q Query) GetTracksByURIs(uris ...string) ([]Track, error) {
+	return q.GetTracksByURIsContext(context.Background(), uris...)
+}
 
-	searchResult := searchResult{}
-	ok := true
+// GetTracksByURIsContext is GetTracksByURIs with a context
+func (q Query) GetTracksByURIsContext(ctx context.Context, uris ...string) (items []Track, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetTracksByURIs")
+	defer func() { span.endItems(err, len(items)) }()
 
-	if searchErr != nil {
-		ok = false
-		fmt.Println("Search Error:", searchErr)
-	}
+	tracks := make([]Track, len(uris))
+	raw, errs := q.getSeveral(ctx, EntityTrack, maxTracksPerRequest, uris)
+	err = decodeSeveral(raw, errs, func(i int) interface{} { return &tracks[i] })
+	return tracks, err
+}
 
-	jsonErr := json.Unmarshal(bytes, &searchResult)
+// Get multiple Tracks by names, resolving several at once (see WithParallelism)
+// Tracks are returned in the order of names
+// On failure the error is a *BatchError and failed Tracks are left empty
+func (q Query) GetTracksByNames(names ...string) ([]Track, error) {
+	return q.GetTracksByNamesContext(context.Background(), names...)
+}
 
-	if jsonErr != nil {
-		ok = false
-		fmt.Println("JSON Error:", jsonErr)
-	}
+// GetTracksByNamesContext is GetTracksByNames with a context
+func (q Query) GetTracksByNamesContext(ctx context.Context, names ...string) (items []Track, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetTracksByNames")
+	defer func() { span.endItems(err, len(items)) }()
 
-	if searchResult.Playlists.Total > 0 {
-		playlist := searchResult.Playlists.Items[0]
-		return playlist, ok
-	}
+	tracks := make([]Track, len(names))
+	errs := make([]error, len(names))
 
-	// No Results Found
-	return Playlist{}, false
+	q.parallel(len(names), func(i int) {
+		tracks[i], errs[i] = q.GetTrackByNameContext(ctx, names[i])
+	})
+	return tracks, batchError(errs)
 }
 
-// Execute HTTP request
-func fetch(token string, uri string, endpoint string) ([]byte, error) {
-	var err error
-	reqClient := &http.Client{}
-	baseURL := "https://api.spotify.com/v1/"
+// Get multiple Albums by IDs, URIs or URLs, fetching up to 20 per request
+// Albums are returned in the order of uris
+// On failure the error is a *BatchError and failed Albums are left empty; Albums
+// that don't exist fail with ErrNotFound
+func (q Query) GetAlbumsByURIs(uris ...string) ([]Album, error) {
+	return q.GetAlbumsByURIsContext(context.Background(), uris...)
+}
 
-	req, httpErr := http.NewRequest("GET", (baseURL + endpoint + "/" + uri), nil)
-	req.Header.Add("Authorization", ("Bearer " + token))
-	req.Header.Add("Content-Type", "application/json")
-	if httpErr != nil {
-		err = httpErr
-	}
+// GetAlbumsByURIsContext is GetAlbumsByURIs with a context
+func (q Query) GetAlbumsByURIsContext(ctx context.Context, uris ...string) (items []Album, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetAlbumsByURIs")
+	defer func() { span.endItems(err, len(items)) }()
 
-	res, fetchErr := reqClient.Do(req)
-	if fetchErr != nil {
-		err = fetchErr
-	}
+	albums := make([]Album, len(uris))
+	raw, errs := q.getSeveral(ctx, EntityAlbum, maxAlbumsPerRequest, uris)
+	err = decodeSeveral(raw, errs, func(i int) interface{} { return &albums[i] })
+	return albums, err
+}
 
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		err = httpErr
-	}
+// Get multiple Albums by names, resolving several at once (see WithParallelism)
+// Albums are returned in the order of names
+// On failure the error is a *BatchError and failed Albums are left empty
+func (q Query) GetAlbumsByNames(names ...string) ([]Album, error) {
+	return q.GetAlbumsByNamesContext(context.Background(), names...)
+}
 
-	defer res.Body.Close()
+// GetAlbumsByNamesContext is GetAlbumsByNames with a context
+func (q Query) GetAlbumsByNamesContext(ctx context.Context, names ...string) (items []Album, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetAlbumsByNames")
+	defer func() { span.endItems(err, len(items)) }()
 
-	if res.StatusCode != 200 {
-		err = errors.New("Status code: " + fmt.Sprint(res.StatusCode))
-	}
+	albums := make([]Album, len(names))
+	errs := make([]error, len(names))
 
-	return body, err
+	q.parallel(len(names), func(i int) {
+		albums[i], errs[i] = q.GetAlbumByNameContext(ctx, names[i])
+	})
+	return albums, batchError(errs)
 }
 
-// Get multiple Tracks by URIs
-func (q Query) GetTracksByURIs(uris ...string) ([]Track, bool) {
-	tracks := make([]Track, 0)
-	ok := true
-
-	for _, x := range uris {
-		track, thisOk := q.GetTrackByURI(x)
-		ok = ok && thisOk
-		tracks = append(tracks, track)
-	}
-	return tracks, ok
+// Get multiple Artists by IDs, URIs or URLs, fetching up to 50 per request
+// Artists are returned in the order of uris
+// On failure the error is a *BatchError and failed Artists are left empty; Artists
+// that don't exist fail with ErrNotFound
+func (q Query) GetArtistsByURIs(uris ...string) ([]Artist, error) {
+	return q.GetArtistsByURIsContext(context.Background(), uris...)
 }
 
-// Get multiple Tracks by names
-func (q Query) GetTracksByNames(names ...string) ([]Track, bool) {
-	tracks := make([]Track, 0)
-	ok := true
+// GetArtistsByURIsContext is GetArtistsByURIs with a context
+func (q Query) GetArtistsByURIsContext(ctx context.Context, uris ...string) (items []Artist, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetArtistsByURIs")
+	defer func() { span.endItems(err, len(items)) }()
 
-	for _, x := range names {
-		track, thisOk := q.GetTrackByName(x)
-		ok = ok && thisOk
-		tracks = append(tracks, track)
-	}
-	return tracks, ok
+	artists := make([]Artist, len(uris))
+	raw, errs := q.getSeveral(ctx, EntityArtist, maxArtistsPerRequest, uris)
+	err = decodeSeveral(raw, errs, func(i int) interface{} { return &artists[i] })
+	return artists, err
 }
 
-// Get multiple Albums by URIs
-func (q Query) GetAlbumsByURIs(uris ...string) ([]Album, bool) {
-	albums := make([]Album, 0)
-	ok := true
-
-	for _, x := range uris {
-		album, thisOk := q.GetAlbumByURI(x)
-		ok = ok && thisOk
-		albums = append(albums, album)
-	}
-	return albums, ok
+// Get multiple Artists by names, resolving several at once (see WithParallelism)
+// Artists are returned in the order of names
+// On failure the error is a *BatchError and failed Artists are left empty
+func (q Query) GetArtistsByNames(names ...string) ([]Artist, error) {
+	return q.GetArtistsByNamesContext(context.Background(), names...)
 }
 
-// Get multiple Albums by names
-func (q Query) GetAlbumsByNames(names ...string) ([]Album, bool) {
-	albums := make([]Album, 0)
-	ok := true
+// GetArtistsByNamesContext is GetArtistsByNames with a context
+func (q Query) GetArtistsByNamesContext(ctx context.Context, names ...string) (items []Artist, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetArtistsByNames")
+	defer func() { span.endItems(err, len(items)) }()
 
-	for _, x := range names {
-		album, thisOk := q.GetAlbumByName(x)
-		ok = ok && thisOk
-		albums = append(albums, album)
-	}
-	return albums, ok
-}
+	artists := make([]Artist, len(names))
+	errs := make([]error, len(names))
 
-// Get multiple Artists by URIs
-func (q Query) GetArtistsByURIs(uris ...string) ([]Artist, bool) {
-	artists := make([]Artist, 0)
-	ok := true
+	q.parallel(len(names), func(i int) {
+		artists[i], errs[i] = q.GetArtistByNameContext(ctx, names[i])
+	})
+	return artists, batchError(errs)
+}
 
-	for _, x := range uris {
-		artist, thisOk := q.GetArtistByURI(x)
-		ok = ok && thisOk
-		artists = append(artists, artist)
-	}
-	return artists, ok
+// Get multiple Playlists by URIs
+// On failure the error is a *BatchError and failed Playlists are left empty
+func (q Query) GetPlaylistsByURIs(uris ...string) ([]Playlist, error) {
+	return q.GetPlaylistsByURIsContext(context.Background(), uris...)
 }
 
-// Get multiple Artists by names
-func (q Query) GetArtistsByNames(names ...string) ([]Artist, bool) {
-	artists := make([]Artist, 0)
-	ok := true
+// GetPlaylistsByURIsContext is GetPlaylistsByURIs with a context
+func (q Query) GetPlaylistsByURIsContext(ctx context.Context, uris ...string) (items []Playlist, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetPlaylistsByURIs")
+	defer func() { span.endItems(err, len(items)) }()
 
-	for _, x := range names {
-		artist, thisOk := q.GetArtistByName(x)
-		ok = ok && thisOk
-		artists = append(artists, artist)
+	playlists := make([]Playlist, len(uris))
+	errs := make([]error, len(uris))
+
+	for i, x := range uris {
+		playlists[i], errs[i] = q.GetPlaylistByURIContext(ctx, x)
 	}
-	return artists, ok
+	return playlists, batchError(errs)
 }
 
-// Get multiple Playlists by URIs
-func (q Query) GetPlaylistsByURIs(uris ...string) ([]Playlist, bool) {
-	playlists := make([]Playlist, 0)
-	ok := true
-
-	for _, x := range uris {
-		playlist, thisOk := q.GetPlaylistByURI(x)
-		ok = ok && thisOk
-		playlists = append(playlists, playlist)
-	}
-	return playlists, ok
+// Get multiple Playlists by names, resolving several at once (see WithParallelism)
+// Playlists are returned in the order of names
+// On failure the error is a *BatchError and failed Playlists are left empty
+func (q Query) GetPlaylistsByNames(names ...string) ([]Playlist, error) {
+	return q.GetPlaylistsByNamesContext(context.Background(), names...)
 }
 
-// Get multiple Playlists by names
-func (q Query) GetPlaylistsByNames(names ...string) ([]Playlist, bool) {
-	playlists := make([]Playlist, 0)
-	ok := true
+// GetPlaylistsByNamesContext is GetPlaylistsByNames with a context
+func (q Query) GetPlaylistsByNamesContext(ctx context.Context, names ...string) (items []Playlist, err error) {
+	ctx, span := q.engine.startCall(ctx, "GetPlaylistsByNames")
+	defer func() { span.endItems(err, len(items)) }()
 
-	for _, x := range names {
-		playlist, thisOk := q.GetPlaylistByName(x)
-		ok = ok && thisOk
-		playlists = append(playlists, playlist)
-	}
-	return playlists, ok
+	playlists := make([]Playlist, len(names))
+	errs := make([]error, len(names))
+
+	q.parallel(len(names), func(i int) {
+		playlists[i], errs[i] = q.GetPlaylistByNameContext(ctx, names[i])
+	})
+	return playlists, batchError(errs)
 }
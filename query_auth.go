@@ -1,41 +1,87 @@
 package spotigo
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Renew client-credentials tokens this long before they expire
+const tokenExpiryDelta = time.Minute
+
 type accessToken struct {
-	Token string `json:"access_token"`
+	Token     string `json:"access_token"`
+	ExpiresIn int    `json:"expires_in"`
 }
 
-// Get authentication token for all non-user account queries
-func getToken(client string, secret string) (string, error) {
-	var err error
-	res, fetchErr := http.PostForm("https://accounts.spotify.com/api/token", url.Values{"grant_type": {"client_credentials"}, "client_id": {client}, "client_secret": {secret}})
+// clientCredentials struct- token source for all non-user account queries
+// Caches the access token, renewing it shortly before it expires
+// Safe for concurrent use; copies of a Query share one clientCredentials
+type clientCredentials struct {
+	httpClient  *http.Client
+	accountsURL string
+	client      string
+	secret      string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Get a valid access token, fetching a new one if none is cached or the
+// cached one is about to expire
+func (c *clientCredentials) get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if fetchErr != nil {
-		err = fetchErr
+	if c.token != "" && time.Now().Add(tokenExpiryDelta).Before(c.expiry) {
+		return c.token, nil
 	}
 
-	if res.StatusCode != 200 {
-		err = errors.New("Status code: " + fmt.Sprint(res.StatusCode))
+	tok, err := getToken(ctx, c.httpClient, c.accountsURL, c.client, c.secret)
+	if err != nil {
+		return "", err
 	}
+	c.token = tok.Token
+	c.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return c.token, nil
+}
 
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		err = readErr
+// Drop a token the API rejected so the next get fetches a new one
+// A token that has already been replaced by another goroutine is left alone
+func (c *clientCredentials) invalidate(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
 	}
+}
 
+// Get authentication token for all non-user account queries
+// accountsURL is the base URL of the Spotify Accounts Service
+func getToken(ctx context.Context, httpClient *http.Client, accountsURL string, client string, secret string) (accessToken, error) {
 	structure := accessToken{}
-	jsonErr := json.Unmarshal(body, &structure)
+	form := url.Values{"grant_type": {"client_credentials"}, "client_id": {client}, "client_secret": {secret}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, accountsURL+"api/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return structure, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	if jsonErr != nil {
-		err = jsonErr
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return structure, err
 	}
-	return structure.Token, err
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return structure, decodeError(res)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&structure)
+	return structure, err
 }
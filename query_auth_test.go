@@ -0,0 +1,138 @@
+package spotigo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+)
+
+func TestQueryRefreshesRejectedToken(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	q := newQuery(t, srv)
+
+	// One 401 costs one new token and one retry
+	srv.ExpireTokens()
+	if _, err := q.GetTrackByURI("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRequests(srv, "POST /api/token"); n != 2 {
+		t.Errorf("%d token requests, want 2", n)
+	}
+	if n := countRequests(srv, "GET /v1/tracks/t1"); n != 2 {
+		t.Errorf("%d track requests, want 2", n)
+	}
+
+	// The new token is kept
+	if _, err := q.GetTrackByURI("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRequests(srv, "POST /api/token"); n != 2 {
+		t.Errorf("%d token requests, want 2", n)
+	}
+}
+
+func TestQueryRefreshesTokenOnceForConcurrentRejections(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+
+	// Hold the first requests until all of them were sent with the old token
+	const calls = 4
+	var mu sync.Mutex
+	held := 0
+	release := make(chan struct{})
+	barrier := func(next http.RoundTripper) http.RoundTripper {
+		return spotigo.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			held++
+			if held == calls {
+				close(release)
+			}
+			first := held <= calls
+			mu.Unlock()
+			if first {
+				<-release
+			}
+			return next.RoundTrip(req)
+		})
+	}
+	q := newQuery(t, srv, spotigo.WithMiddleware(barrier))
+	srv.ExpireTokens()
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.GetTrackByURI("t1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each call drops the token it was refused with; only the first of them
+	// clears the cached token, so it is fetched again just once
+	if n := countRequests(srv, "POST /api/token"); n != 2 {
+		t.Errorf("%d token requests, want 2", n)
+	}
+}
+
+// A client to srv whose token responses say they expire after lifetime
+func shortLivedTokens(srv *spotigotest.Server, lifetime time.Duration) *http.Client {
+	client := *srv.Client()
+	next := client.Transport
+	client.Transport = spotigo.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		res, err := next.RoundTrip(req)
+		if err != nil || req.URL.Path != "/api/token" || res.StatusCode != http.StatusOK {
+			return res, err
+		}
+		defer res.Body.Close()
+		tok := make(map[string]interface{})
+		if err := json.NewDecoder(res.Body).Decode(&tok); err != nil {
+			return nil, err
+		}
+		tok["expires_in"] = int(lifetime / time.Second)
+		b, _ := json.Marshal(tok)
+		res.Body = ioutil.NopCloser(bytes.NewReader(b))
+		res.ContentLength = int64(len(b))
+		return res, nil
+	})
+	return &client
+}
+
+func TestQueryRenewsTokenBeforeExpiry(t *testing.T) {
+	tests := []struct {
+		lifetime time.Duration
+		// Token requests for NewQuery and two calls
+		want int
+	}{
+		{time.Hour, 1},
+		{2 * time.Minute, 1},
+		// Within a minute of expiry, so renewed for every call
+		{59 * time.Second, 3},
+	}
+	for _, tt := range tests {
+		srv := newServer(t)
+		srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+		q := newQuery(t, srv, spotigo.WithHTTPClient(shortLivedTokens(srv, tt.lifetime)))
+		for i := 0; i < 2; i++ {
+			if _, err := q.GetTrackByURI("t1"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n := countRequests(srv, "POST /api/token"); n != tt.want {
+			t.Errorf("lifetime %v: %d token requests, want %d", tt.lifetime, n, tt.want)
+		}
+		if n := countRequests(srv, "GET /v1/tracks/t1"); n != 2 {
+			t.Errorf("lifetime %v: %d track requests, want 2", tt.lifetime, n)
+		}
+	}
+}
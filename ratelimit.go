@@ -0,0 +1,364 @@
+package spotigo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults of a RateLimiter
+// Spotify doesn't publish its limit, which is counted over a rolling 30
+// seconds; these keep a single app well clear of it
+const (
+	defaultRequestRate   = 10
+	defaultRequestBurst  = 20
+	defaultMaxConcurrent = 8
+	defaultMaxAttempts   = 4
+	defaultBackoffBase   = 500 * time.Millisecond
+	defaultBackoffMax    = 30 * time.Second
+)
+
+// RateLimiter paces the requests of every Query and User it is given to and
+// retries the ones that fail
+// Requests are started at a steady rate from a token bucket, with a cap on
+// how many are in flight at once. A 429 response pauses all requests for its
+// Retry-After duration before the request is retried, unless Retry-After is
+// longer than the maximum back-off, in which case the request fails with an
+// *Error matching ErrRateLimited instead of waiting. 5xx responses and
+// network errors are retried with exponential back-off and jitter, for GET,
+// HEAD, PUT and DELETE requests only: a POST, such as skipping to the next
+// track, may have taken effect, so only its 429s are retried. A request is
+// attempted at most a limited number of times, after which its last response
+// or error is returned
+// Safe for concurrent use
+type RateLimiter struct {
+	rate          float64
+	burst         int
+	maxConcurrent int
+	maxAttempts   int
+	backoffBase   time.Duration
+	backoffMax    time.Duration
+
+	// Slots of requests in flight
+	slots chan struct{}
+
+	mu sync.Mutex
+	// Tokens left in the bucket as of last
+	tokens float64
+	last   time.Time
+	// No request starts before pausedUntil, set by Retry-After
+	pausedUntil time.Time
+}
+
+// RateLimitOption configures a RateLimiter
+type RateLimitOption func(*RateLimiter)
+
+// Start at most perSecond requests a second on average, and at most burst at
+// once after a quiet spell (default 10 and 20); perSecond 0 removes the limit
+func RequestRate(perSecond float64, burst int) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.rate = perSecond
+		l.burst = burst
+	}
+}
+
+// Have at most n requests in flight at once (default 8); 0 removes the limit
+func MaxConcurrentRequests(n int) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.maxConcurrent = n
+	}
+}
+
+// Make at most n attempts at each request (default 4); 1 disables retries
+func MaxAttempts(n int) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.maxAttempts = n
+	}
+}
+
+// Back off from base after the first failure, doubling up to max (default
+// 500ms and 30s); each delay is randomized between half and all of its value
+func RetryBackoff(base, max time.Duration) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.backoffBase = base
+		l.backoffMax = max
+	}
+}
+
+// Create a RateLimiter
+func NewRateLimiter(opts ...RateLimitOption) *RateLimiter {
+	l := &RateLimiter{
+		rate:          defaultRequestRate,
+		burst:         defaultRequestBurst,
+		maxConcurrent: defaultMaxConcurrent,
+		maxAttempts:   defaultMaxAttempts,
+		backoffBase:   defaultBackoffBase,
+		backoffMax:    defaultBackoffMax,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.burst < 1 {
+		l.burst = 1
+	}
+	if l.maxAttempts < 1 {
+		l.maxAttempts = 1
+	}
+	if l.maxConcurrent > 0 {
+		l.slots = make(chan struct{}, l.maxConcurrent)
+	}
+	l.tokens = float64(l.burst)
+	l.last = time.Now()
+	return l
+}
+
+// Send the requests of a Query or User through limiter
+// By default every Query and User of the same app (client ID) shares one
+// RateLimiter with default settings, since Spotify counts requests per app;
+// give several the same limiter to share a budget with other settings
+// nil turns off pacing and retries
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(o *options) {
+		o.rateLimiter = limiter
+		o.rateLimiterSet = true
+	}
+}
+
+// The RateLimiters shared by default, by client ID and Web API URL
+var sharedLimiters = struct {
+	mu       sync.Mutex
+	limiters map[string]*RateLimiter
+}{limiters: make(map[string]*RateLimiter)}
+
+// Use the default RateLimiter of clientID unless WithRateLimiter was given
+func (o *options) shareRateLimiter(clientID string) {
+	if o.rateLimiterSet {
+		return
+	}
+	key := clientID + " " + o.apiURL
+	sharedLimiters.mu.Lock()
+	defer sharedLimiters.mu.Unlock()
+	l, ok := sharedLimiters.limiters[key]
+	if !ok {
+		l = NewRateLimiter()
+		sharedLimiters.limiters[key] = l
+	}
+	o.rateLimiter = l
+}
+
+// Wrap an HTTP client's transport so its requests go through l
+// retried, if not nil, is called before each retry with the status that
+// caused it, or 0 after a network error
+// A nil l returns the client unchanged
+func (l *RateLimiter) client(c *http.Client, retried func(req *http.Request, status int)) *http.Client {
+	if l == nil {
+		return c
+	}
+	wrapped := *c
+	wrapped.Transport = &rateLimitedTransport{limiter: l, next: c.Transport, retried: retried}
+	return &wrapped
+}
+
+// rateLimitedTransport struct- an http.RoundTripper sending requests through
+// a RateLimiter
+type rateLimitedTransport struct {
+	limiter *RateLimiter
+	next    http.RoundTripper
+	retried func(req *http.Request, status int)
+}
+
+// Send a request, waiting for the limiter and retrying as it allows
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.limiter
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			// The body was consumed by the last attempt
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req = req.Clone(ctx)
+				req.Body = body
+			}
+		}
+
+		if err := l.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err := l.acquire(ctx); err != nil {
+			return nil, err
+		}
+		res, err := next.RoundTrip(req)
+		l.release()
+
+		delay, retry := l.retryDelay(req, res, err, attempt)
+		if !retry || attempt >= l.maxAttempts || (req.Body != nil && req.GetBody == nil) {
+			return res, err
+		}
+		status := 0
+		if res != nil {
+			status = res.StatusCode
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		if t.retried != nil {
+			t.retried(req, status)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Wait until a request may start: until any Retry-After pause is over and a
+// token is in the bucket, which is then taken
+func (l *RateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		var delay time.Duration
+		switch {
+		case now.Before(l.pausedUntil):
+			delay = l.pausedUntil.Sub(now)
+		case l.rate <= 0:
+			l.mu.Unlock()
+			return nil
+		default:
+			l.tokens += now.Sub(l.last).Seconds() * l.rate
+			if l.tokens > float64(l.burst) {
+				l.tokens = float64(l.burst)
+			}
+			l.last = now
+			if l.tokens >= 1 {
+				l.tokens--
+				l.mu.Unlock()
+				return nil
+			}
+			delay = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Take a slot for a request in flight
+func (l *RateLimiter) acquire(ctx context.Context) error {
+	if l.slots == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Free a request's slot
+func (l *RateLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// Whether the outcome of an attempt should be retried, and after how long
+// A 429 also pauses every other request of the limiter, for at most the
+// maximum back-off; one asking for longer isn't retried
+func (l *RateLimiter) retryDelay(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+	switch {
+	case err != nil:
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		return l.backoff(attempt), idempotent(req.Method)
+	case res.StatusCode == http.StatusTooManyRequests:
+		delay, ok := retryAfter(res)
+		if !ok {
+			delay = l.backoff(attempt)
+		}
+		retry := delay <= l.backoffMax
+		if !retry {
+			delay = l.backoffMax
+		}
+		l.mu.Lock()
+		if until := time.Now().Add(delay); until.After(l.pausedUntil) {
+			l.pausedUntil = until
+		}
+		l.mu.Unlock()
+		return delay, retry
+	case res.StatusCode >= 500:
+		return l.backoff(attempt), idempotent(req.Method)
+	default:
+		return 0, false
+	}
+}
+
+// Whether a request can be repeated without changing its effect, so that it
+// can be retried after a failure that may have happened after it was served
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Exponential back-off with jitter before attempt+1
+func (l *RateLimiter) backoff(attempt int) time.Duration {
+	d := l.backoffBase
+	for i := 1; i < attempt && d < l.backoffMax; i++ {
+		d *= 2
+	}
+	if d > l.backoffMax {
+		d = l.backoffMax
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// The delay a response's Retry-After header asks for, in seconds or as a date
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	raw := res.Header.Get("Retry-After")
+	if raw == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(raw); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Wait for d, returning the context's error early if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,231 @@
+package spotigo_test
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+)
+
+func TestRateLimiterRetriesServerErrors(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	q := newQuery(t, srv)
+
+	srv.FailNext(2, http.StatusServiceUnavailable, 0)
+	if _, err := q.GetTrackByURI("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRequests(srv, "GET /v1/tracks/t1"); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+}
+
+func TestRateLimiterMaxAttempts(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	limiter := spotigo.NewRateLimiter(spotigo.MaxAttempts(2), spotigo.RetryBackoff(time.Millisecond, time.Millisecond))
+	q := newQuery(t, srv, spotigo.WithRateLimiter(limiter))
+
+	srv.FailNext(3, http.StatusBadGateway, 0)
+	if _, err := q.GetTrackByURI("t1"); err == nil {
+		t.Error("no error after running out of attempts")
+	}
+	if n := countRequests(srv, "GET /v1/tracks/t1"); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50), newTrack("t2", "Juno", "Remi Wolf", 50))
+	limiter := spotigo.NewRateLimiter(spotigo.RequestRate(0, 0))
+	q := newQuery(t, srv, spotigo.WithRateLimiter(limiter))
+	other := newQuery(t, srv, spotigo.WithRateLimiter(limiter))
+	unshared := newQuery(t, srv)
+
+	srv.FailNext(1, http.StatusTooManyRequests, time.Second)
+	start := time.Now()
+	if _, err := q.GetTrackByURI("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, before Retry-After", elapsed)
+	}
+
+	checkPauseShared(t, srv, q, other, unshared)
+}
+
+func TestDefaultRateLimiterShared(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50), newTrack("t2", "Juno", "Remi Wolf", 50))
+	newApp := func(client string) spotigo.Query {
+		q, err := spotigo.NewQuery(client, "secret", srv.Options()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q
+	}
+	// Client IDs of their own, so no other test's limiter is shared
+	q, other, unshared := newApp("shared-a"), newApp("shared-a"), newApp("shared-b")
+	checkPauseShared(t, srv, q, other, unshared)
+}
+
+func TestRateLimiterLongRetryAfter(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	limiter := spotigo.NewRateLimiter(spotigo.RequestRate(0, 0), spotigo.RetryBackoff(time.Millisecond, 100*time.Millisecond))
+	q := newQuery(t, srv, spotigo.WithRateLimiter(limiter))
+
+	// A pause longer than the maximum back-off fails the request at once
+	srv.FailNext(1, http.StatusTooManyRequests, 2*time.Second)
+	start := time.Now()
+	_, err := q.GetTrackByURI("t1")
+	if !errors.Is(err, spotigo.ErrRateLimited) {
+		t.Fatalf("got %v, want ErrRateLimited", err)
+	}
+	var apiErr *spotigo.Error
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 2*time.Second {
+		t.Errorf("error %#v, want RetryAfter 2s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("failed after %v", elapsed)
+	}
+	if n := countRequests(srv, "GET /v1/tracks/t1"); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+
+	// Other requests are paused for the maximum back-off only
+	start = time.Now()
+	if _, err := q.GetTrackByURI("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("next request waited %v", elapsed)
+	}
+}
+
+func TestRateLimiterRetriesPOSTOnlyOn429(t *testing.T) {
+	srv := newServer(t)
+	srv.AddDevice(spotigotest.DefaultUserID, spotigo.Device{ID: "d1", Name: "Laptop", Active: true})
+	u := newUser(t, srv)
+
+	srv.FailNext(1, http.StatusServiceUnavailable, 0)
+	if err := u.SkipToNext(); err == nil {
+		t.Error("no error for a 503")
+	}
+	if n := countRequests(srv, "POST /v1/me/player/next"); n != 1 {
+		t.Errorf("after a 503: %d requests, want 1", n)
+	}
+
+	srv.FailNext(1, http.StatusTooManyRequests, 0)
+	if err := u.SkipToNext(); err != nil {
+		t.Error(err)
+	}
+	if n := countRequests(srv, "POST /v1/me/player/next"); n != 3 {
+		t.Errorf("after a 429: %d requests, want 3", n)
+	}
+}
+
+// Check that a 429 with Retry-After for q's request of t1 pauses shared's
+// request of t2, and not unshared's
+func checkPauseShared(t *testing.T, srv *spotigotest.Server, q, shared, unshared spotigo.Query) {
+	t.Helper()
+	before := countRequests(srv, "GET /v1/tracks/t1")
+	srv.FailNext(1, http.StatusTooManyRequests, time.Second)
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.GetTrackByURI("t1")
+		done <- err
+	}()
+	for countRequests(srv, "GET /v1/tracks/t1") == before {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if _, err := unshared.GetTrackByURI("t2"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("client with another limiter waited %v", elapsed)
+	}
+	if _, err := shared.GetTrackByURI("t2"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("client sharing the limiter waited only %v", elapsed)
+	}
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRateLimiterRequestRate(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+	// The bucket holds 2 tokens and refills at 20 a second: 2 requests start
+	// at once and the other 4 every 50ms
+	limiter := spotigo.NewRateLimiter(spotigo.RequestRate(20, 2))
+	q := newQuery(t, srv, spotigo.WithRateLimiter(limiter))
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.GetTrackByURI("t1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("6 requests took %v, want at least 200ms", elapsed)
+	}
+}
+
+func TestRateLimiterMaxConcurrentRequests(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50))
+
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	slow := func(next http.RoundTripper) http.RoundTripper {
+		return spotigo.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > peak {
+				peak = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			res, err := next.RoundTrip(req)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return res, err
+		})
+	}
+	limiter := spotigo.NewRateLimiter(spotigo.RequestRate(0, 0), spotigo.MaxConcurrentRequests(2))
+	q := newQuery(t, srv, spotigo.WithRateLimiter(limiter), spotigo.WithMiddleware(slow))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.GetTrackByURI("t1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak != 2 {
+		t.Errorf("%d requests in flight at once, want 2", peak)
+	}
+}
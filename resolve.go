@@ -0,0 +1,165 @@
+package spotigo
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Methods that accept "a string search query or a struct" resolve their
+// arguments to IDs here; a string or SearchQuery is searched for and the
+// best match is used, unless the string is a Spotify URI, URL or bare ID
+
+// Resolve a Track or track search query to a track ID
+func (q Query) trackID(ctx context.Context, i interface{}) (string, error) {
+	switch v := i.(type) {
+	case string:
+		if isSpotifyRef(v) {
+			return parseIDOf(v, EntityTrack)
+		}
+		track, err := q.GetTrackByNameContext(ctx, v)
+		return track.ID, err
+	case SearchQuery:
+		track, err := q.GetTrackByNameContext(ctx, v.String())
+		return track.ID, err
+	case ID:
+		return v.of(EntityTrack)
+	case Track:
+		return v.ID, nil
+	// Invalid Type
+	default:
+		return "", invalidInput("expected string, SearchQuery, ID or Track, got %T", i)
+	}
+}
+
+// Resolve an Album or album search query to an album ID
+func (q Query) albumID(ctx context.Context, i interface{}) (string, error) {
+	switch v := i.(type) {
+	case string:
+		if isSpotifyRef(v) {
+			return parseIDOf(v, EntityAlbum)
+		}
+		album, err := q.GetAlbumByNameContext(ctx, v)
+		return album.ID, err
+	case SearchQuery:
+		album, err := q.GetAlbumByNameContext(ctx, v.String())
+		return album.ID, err
+	case ID:
+		return v.of(EntityAlbum)
+	case Album:
+		return v.ID, nil
+	// Invalid Type
+	default:
+		return "", invalidInput("expected string, SearchQuery, ID or Album, got %T", i)
+	}
+}
+
+// Resolve an Artist or artist search query to an artist ID
+func (q Query) artistID(ctx context.Context, i interface{}) (string, error) {
+	switch v := i.(type) {
+	case string:
+		if isSpotifyRef(v) {
+			return parseIDOf(v, EntityArtist)
+		}
+		artist, err := q.GetArtistByNameContext(ctx, v)
+		return artist.ID, err
+	case SearchQuery:
+		artist, err := q.GetArtistByNameContext(ctx, v.String())
+		return artist.ID, err
+	case ID:
+		return v.of(EntityArtist)
+	case Artist:
+		return v.ID, nil
+	// Invalid Type
+	default:
+		return "", invalidInput("expected string, SearchQuery, ID or Artist, got %T", i)
+	}
+}
+
+// Resolve a Playlist or playlist search query to a playlist ID
+func (q Query) playlistID(ctx context.Context, i interface{}) (string, error) {
+	switch v := i.(type) {
+	case string:
+		if isSpotifyRef(v) {
+			return parseIDOf(v, EntityPlaylist)
+		}
+		playlist, err := q.GetPlaylistByNameContext(ctx, v)
+		return playlist.ID, err
+	case SearchQuery:
+		playlist, err := q.GetPlaylistByNameContext(ctx, v.String())
+		return playlist.ID, err
+	case ID:
+		return v.of(EntityPlaylist)
+	case Playlist:
+		return v.ID, nil
+	// Invalid Type
+	default:
+		return "", invalidInput("expected string, SearchQuery, ID or Playlist, got %T", i)
+	}
+}
+
+// Resolve a Profile, user URI or URL, or username to a user ID
+// Users can't be searched for, so any other string is taken as a username
+func (q Query) userID(ctx context.Context, i interface{}) (string, error) {
+	switch v := i.(type) {
+	case string:
+		if isSpotifyLink(v) {
+			return parseIDOf(v, EntityUser)
+		}
+		if v = strings.TrimSpace(v); v == "" {
+			return "", invalidInput("empty username")
+		}
+		return v, nil
+	case ID:
+		return v.of(EntityUser)
+	case Profile:
+		return v.ID, nil
+	// Invalid Type
+	default:
+		return "", invalidInput("expected string, ID or Profile, got %T", i)
+	}
+}
+
+// Resolve each item with resolve, stopping at the first failure
+func resolveAll(ctx context.Context, resolve func(context.Context, interface{}) (string, error), items []interface{}) ([]string, error) {
+	if len(items) == 0 {
+		return nil, invalidInput("no items given")
+	}
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		id, err := resolve(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Call do with each index below n, on up to q.parallelism goroutines at once
+func (q Query) parallel(n int, do func(i int)) {
+	workers := q.parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				do(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
@@ -0,0 +1,72 @@
+package spotigo_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+)
+
+func TestGetTracksByNamesKeepsOrder(t *testing.T) {
+	srv := newServer(t)
+	names := []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo", "Foxtrot", "Missing", "Hotel", "India", "Juliett"}
+	for _, name := range names {
+		if name != "Missing" {
+			srv.AddTrack(newTrack(strings.ToLower(name), name, "Remi Wolf", 50))
+		}
+	}
+
+	// Earlier searches answer later, so they finish out of order
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	slow := func(next http.RoundTripper) http.RoundTripper {
+		return spotigo.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+
+			for i, name := range names {
+				if strings.Contains(req.URL.RawQuery, name) {
+					time.Sleep(time.Duration(len(names)-i) * 2 * time.Millisecond)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+	q := newQuery(t, srv, spotigo.WithParallelism(4), spotigo.WithMiddleware(slow))
+
+	tracks, err := q.GetTracksByNames(names...)
+	var batch *spotigo.BatchError
+	if !errors.As(err, &batch) {
+		t.Fatalf("got %v, want a *BatchError", err)
+	}
+	for i, name := range names {
+		if name == "Missing" {
+			if batch.Errs[i] == nil || tracks[i].ID != "" {
+				t.Errorf("names[%d]: got %q, %v, want an error", i, tracks[i].ID, batch.Errs[i])
+			}
+			continue
+		}
+		if batch.Errs[i] != nil {
+			t.Errorf("names[%d]: %v", i, batch.Errs[i])
+		}
+		if want := strings.ToLower(name); tracks[i].ID != want {
+			t.Errorf("tracks[%d] = %q, want %q", i, tracks[i].ID, want)
+		}
+	}
+	if maxInFlight < 2 || maxInFlight > 4 {
+		t.Errorf("up to %d requests at once, want 2 to 4", maxInFlight)
+	}
+}
@@ -1,64 +1,51 @@
 package spotigo
 
 import (
-	"encoding/json"
-	"log"
+	"context"
 	"net/http"
+	"strings"
 )
 
 // Save tracks for User
-func (u *User) SaveTracks(q Query, i ...interface{}) bool {
-	return u.modifySavedTracks(q, true, i...)
+func (u *User) SaveTracks(q Query, i ...interface{}) error {
+	return u.SaveTracksContext(context.Background(), q, i...)
+}
+
+// SaveTracksContext is SaveTracks with a context
+func (u *User) SaveTracksContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SaveTracks")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SaveTracks"); err != nil {
+		return err
+	}
+	return u.modifySavedTracks(ctx, q, true, i...)
 }
 
 // Unsave tracks for User
-func (u *User) UnsaveTracks(q Query, i ...interface{}) bool {
-	return u.modifySavedTracks(q, false, i...)
+func (u *User) UnsaveTracks(q Query, i ...interface{}) error {
+	return u.UnsaveTracksContext(context.Background(), q, i...)
 }
 
-// Execute saving/unsaving of tracks
-func (u *User) modifySavedTracks(q Query, save bool, i ...interface{}) bool {
-	uris := make([]string, 0)
-	ok := true
-
-	for _, item := range i {
-		switch v := item.(type) {
-		case string:
-			bytes, searchErr := q.search(v, "track")
-			if searchErr != nil {
-				ok = false
-			}
-
-			searchResult := searchResult{}
-			jsonErr := json.Unmarshal(bytes, &searchResult)
-
-			if jsonErr != nil {
-				log.Fatal("JSON Error:", jsonErr)
-			}
-
-			if searchResult.Tracks.Total > 0 {
-				track := searchResult.Tracks.Items[0]
-				uri := track.ID
-				uris = append(uris, uri)
-			}
-		case Track:
-			uri := v.ID
-			uris = append(uris, uri)
-		// Invalid Type
-		default:
-			ok = false
-		}
+// UnsaveTracksContext is UnsaveTracks with a context
+func (u *User) UnsaveTracksContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "UnsaveTracks")
+	defer span.end(&err)
 
-		if !ok {
-			return ok
-		}
+	if err := u.checkScopes("UnsaveTracks"); err != nil {
+		return err
 	}
+	return u.modifySavedTracks(ctx, q, false, i...)
+}
 
-	reqURL := u.baseURL + "me/tracks?ids="
-	for _, uri := range uris {
-		reqURL += uri + ","
+// Execute saving/unsaving of tracks
+func (u *User) modifySavedTracks(ctx context.Context, q Query, save bool, i ...interface{}) error {
+	uris, err := resolveAll(ctx, q.trackID, i)
+	if err != nil {
+		return err
 	}
-	reqURL = reqURL[:len(reqURL)-1]
+
+	reqURL := u.baseURL + "me/tracks?ids=" + strings.Join(uris, ",")
 
 	method := ""
 	if save {
@@ -67,74 +54,88 @@ func (u *User) modifySavedTracks(q Query, save bool, i ...interface{}) bool {
 		method = http.MethodDelete
 	}
 
-	ok = u.sendRequest(method, reqURL) && ok
-
-	return ok
+	return u.sendRequest(ctx, method, reqURL)
 }
 
 // Follow Artists for User
-func (u *User) FollowArtists(q Query, i ...interface{}) bool {
-	return u.modifyFollowees(q, true, true, i...)
+func (u *User) FollowArtists(q Query, i ...interface{}) error {
+	return u.FollowArtistsContext(context.Background(), q, i...)
+}
+
+// FollowArtistsContext is FollowArtists with a context
+func (u *User) FollowArtistsContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "FollowArtists")
+	defer span.end(&err)
+
+	if err := u.checkScopes("FollowArtists"); err != nil {
+		return err
+	}
+	return u.modifyFollowees(ctx, q, true, true, i...)
 }
 
 // Unfollow Artists for User
-func (u *User) UnfollowArtists(q Query, i ...interface{}) bool {
-	return u.modifyFollowees(q, false, true, i...)
+func (u *User) UnfollowArtists(q Query, i ...interface{}) error {
+	return u.UnfollowArtistsContext(context.Background(), q, i...)
 }
 
-// Follow Users for User
-func (u *User) FollowUsers(q Query, i ...interface{}) bool {
-	return u.modifyFollowees(q, true, false, i...)
+// UnfollowArtistsContext is UnfollowArtists with a context
+func (u *User) UnfollowArtistsContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "UnfollowArtists")
+	defer span.end(&err)
+
+	if err := u.checkScopes("UnfollowArtists"); err != nil {
+		return err
+	}
+	return u.modifyFollowees(ctx, q, false, true, i...)
 }
 
-// Unfollow Users for User
-func (u *User) UnfollowUsers(q Query, i ...interface{}) bool {
-	return u.modifyFollowees(q, false, false, i...)
+// Follow Users for User, given as usernames, user URIs or URLs, IDs or
+// Profiles
+func (u *User) FollowUsers(q Query, i ...interface{}) error {
+	return u.FollowUsersContext(context.Background(), q, i...)
 }
 
-// Execute following/unfollowing of artists or users
-func (u *User) modifyFollowees(q Query, follow bool, artist bool, i ...interface{}) bool {
-	uris := make([]string, 0)
-	ok := true
-
-	for _, item := range i {
-		switch v := item.(type) {
-		case string:
-			bytes, searchErr := q.search(v, "artist")
-			if searchErr != nil {
-				ok = false
-			}
-
-			searchResult := searchResult{}
-			jsonErr := json.Unmarshal(bytes, &searchResult)
-
-			if jsonErr != nil {
-				log.Fatal("JSON Error:", jsonErr)
-			}
-
-			if searchResult.Artists.Total > 0 {
-				artist := searchResult.Artists.Items[0]
-				uri := artist.ID
-				uris = append(uris, uri)
-			}
-		case Artist:
-			uri := v.ID
-			uris = append(uris, uri)
-		// Invalid Type
-		default:
-			ok = false
-		}
+// FollowUsersContext is FollowUsers with a context
+func (u *User) FollowUsersContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "FollowUsers")
+	defer span.end(&err)
 
-		if !ok {
-			return ok
-		}
+	if err := u.checkScopes("FollowUsers"); err != nil {
+		return err
 	}
+	return u.modifyFollowees(ctx, q, true, false, i...)
+}
 
-	reqURL := u.baseURL + "me/following?ids="
-	for _, uri := range uris {
-		reqURL += uri + ","
+// Unfollow Users for User, given as for FollowUsers
+func (u *User) UnfollowUsers(q Query, i ...interface{}) error {
+	return u.UnfollowUsersContext(context.Background(), q, i...)
+}
+
+// UnfollowUsersContext is UnfollowUsers with a context
+func (u *User) UnfollowUsersContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "UnfollowUsers")
+	defer span.end(&err)
+
+	if err := u.checkScopes("UnfollowUsers"); err != nil {
+		return err
+	}
+	return u.modifyFollowees(ctx, q, false, false, i...)
+}
+
+// Execute following/unfollowing of artists or users
+// Artist strings are resolved by artist search; user strings are usernames,
+// or user URIs and URLs
+func (u *User) modifyFollowees(ctx context.Context, q Query, follow bool, artist bool, i ...interface{}) error {
+	resolve := q.userID
+	if artist {
+		resolve = q.artistID
+	}
+	uris, err := resolveAll(ctx, resolve, i)
+	if err != nil {
+		return err
 	}
-	reqURL = reqURL[:len(reqURL)-1]
+
+	reqURL := u.baseURL + "me/following?ids=" + strings.Join(uris, ",")
 
 	qType := ""
 	if artist {
@@ -151,23 +152,43 @@ func (u *User) modifyFollowees(q Query, follow bool, artist bool, i ...interface
 		method = http.MethodDelete
 	}
 
-	ok = u.sendRequest(method, reqURL) && ok
-
-	return ok
+	return u.sendRequest(ctx, method, reqURL)
 }
 
 // Save Playlists for User
-func (u *User) SavePlaylists(q Query, i ...interface{}) bool {
-	return u.modifySavedPlaylists(q, true, i...)
+func (u *User) SavePlaylists(q Query, i ...interface{}) error {
+	return u.SavePlaylistsContext(context.Background(), q, i...)
+}
+
+// SavePlaylistsContext is SavePlaylists with a context
+func (u *User) SavePlaylistsContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SavePlaylists")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SavePlaylists"); err != nil {
+		return err
+	}
+	return u.modifySavedPlaylists(ctx, q, true, i...)
 }
 
 // Unsave Playlists for User
-func (u *User) UnsavePlaylists(q Query, i ...interface{}) bool {
-	return u.modifySavedPlaylists(q, false, i...)
+func (u *User) UnsavePlaylists(q Query, i ...interface{}) error {
+	return u.UnsavePlaylistsContext(context.Background(), q, i...)
+}
+
+// UnsavePlaylistsContext is UnsavePlaylists with a context
+func (u *User) UnsavePlaylistsContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "UnsavePlaylists")
+	defer span.end(&err)
+
+	if err := u.checkScopes("UnsavePlaylists"); err != nil {
+		return err
+	}
+	return u.modifySavedPlaylists(ctx, q, false, i...)
 }
 
 // Execute saving/unsaving of playlists
-func (u *User) modifySavedPlaylists(q Query, save bool, i ...interface{}) bool {
+func (u *User) modifySavedPlaylists(ctx context.Context, q Query, save bool, i ...interface{}) error {
 
 	method := ""
 	if save {
@@ -176,100 +197,62 @@ func (u *User) modifySavedPlaylists(q Query, save bool, i ...interface{}) bool {
 		method = http.MethodDelete
 	}
 
-	uri := ""
-	ok := true
-
-	for _, item := range i {
-		switch v := item.(type) {
-		case string:
-			bytes, searchErr := q.search(v, "playlist")
-			if searchErr != nil {
-				ok = false
-			}
-
-			searchResult := searchResult{}
-			jsonErr := json.Unmarshal(bytes, &searchResult)
-
-			if jsonErr != nil {
-				log.Fatal("JSON Error:", jsonErr)
-			}
-
-			if searchResult.Playlists.Total > 0 {
-				playlist := searchResult.Playlists.Items[0]
-				uri = playlist.ID
-			}
-		case Playlist:
-			uri = v.ID
-		// Invalid Type
-		default:
-			ok = false
-		}
-
-		if !ok {
-			return ok
-		}
+	uris, err := resolveAll(ctx, q.playlistID, i)
+	if err != nil {
+		return err
+	}
 
+	// Playlists can only be followed one at a time
+	for _, uri := range uris {
 		reqURL := u.baseURL + "playlists/" + uri + "/followers"
-		ok = u.sendRequest(method, reqURL) && ok
-
+		if err := u.sendRequest(ctx, method, reqURL); err != nil {
+			return err
+		}
 	}
 
-	return ok
+	return nil
 }
 
 // Save Albums for User
-func (u *User) SaveAlbums(q Query, i ...interface{}) bool {
-	return u.modifySavedAlbums(q, true, i...)
+func (u *User) SaveAlbums(q Query, i ...interface{}) error {
+	return u.SaveAlbumsContext(context.Background(), q, i...)
+}
+
+// SaveAlbumsContext is SaveAlbums with a context
+func (u *User) SaveAlbumsContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "SaveAlbums")
+	defer span.end(&err)
+
+	if err := u.checkScopes("SaveAlbums"); err != nil {
+		return err
+	}
+	return u.modifySavedAlbums(ctx, q, true, i...)
 }
 
 // Unsave Albums for User
-func (u *User) UnsaveAlbums(q Query, i ...interface{}) bool {
-	return u.modifySavedAlbums(q, false, i...)
+func (u *User) UnsaveAlbums(q Query, i ...interface{}) error {
+	return u.UnsaveAlbumsContext(context.Background(), q, i...)
 }
 
-// Execute saving/unsaving of albums
-func (u *User) modifySavedAlbums(q Query, save bool, i ...interface{}) bool {
-	uris := make([]string, 0)
-	ok := true
-
-	for _, item := range i {
-		switch v := item.(type) {
-		case string:
-			bytes, searchErr := q.search(v, "album")
-			if searchErr != nil {
-				ok = false
-			}
-
-			searchResult := searchResult{}
-			jsonErr := json.Unmarshal(bytes, &searchResult)
-
-			if jsonErr != nil {
-				log.Fatal("JSON Error:", jsonErr)
-			}
-
-			if searchResult.Albums.Total > 0 {
-				album := searchResult.Albums.Items[0]
-				uri := album.ID
-				uris = append(uris, uri)
-			}
-		case Album:
-			uri := v.ID
-			uris = append(uris, uri)
-			// Invalid Type
-		default:
-			ok = false
-		}
+// UnsaveAlbumsContext is UnsaveAlbums with a context
+func (u *User) UnsaveAlbumsContext(ctx context.Context, q Query, i ...interface{}) (err error) {
+	ctx, span := u.engine.startCall(ctx, "UnsaveAlbums")
+	defer span.end(&err)
 
-		if !ok {
-			return ok
-		}
+	if err := u.checkScopes("UnsaveAlbums"); err != nil {
+		return err
 	}
+	return u.modifySavedAlbums(ctx, q, false, i...)
+}
 
-	reqURL := u.baseURL + "me/albums?ids="
-	for _, uri := range uris {
-		reqURL += uri + ","
+// Execute saving/unsaving of albums
+func (u *User) modifySavedAlbums(ctx context.Context, q Query, save bool, i ...interface{}) error {
+	uris, err := resolveAll(ctx, q.albumID, i)
+	if err != nil {
+		return err
 	}
-	reqURL = reqURL[:len(reqURL)-1]
+
+	reqURL := u.baseURL + "me/albums?ids=" + strings.Join(uris, ",")
 
 	method := ""
 	if save {
@@ -278,200 +261,90 @@ func (u *User) modifySavedAlbums(q Query, save bool, i ...interface{}) bool {
 		method = http.MethodDelete
 	}
 
-	ok = u.sendRequest(method, reqURL) && ok
-
-	return ok
-}
-
-// Search Result struct- maps to Spotify JSON response format by tag `json: "var_name"`
-// Struct generated by putting Spotify JSON data into JSON to Go struct generator at:
-// https://mholt.github.io/json-to-go/
-type searchResult struct {
-	Tracks struct {
-		Href     string  `json:"href"`
-		Items    []Track `json:"items"`
-		Limit    int     `json:"limit"`
-		Next     string  `json:"next"`
-		Offset   int     `json:"offset"`
-		Previous string  `json:"previous"`
-		Total    int     `json:"total"`
-	} `json:"tracks"`
-	Artists struct {
-		Href     string   `json:"href"`
-		Items    []Artist `json:"items"`
-		Limit    int      `json:"limit"`
-		Next     string   `json:"next"`
-		Offset   int      `json:"offset"`
-		Previous string   `json:"previous"`
-		Total    int      `json:"total"`
-	} `json:"artists"`
-	Albums struct {
-		Href     string  `json:"href"`
-		Items    []Album `json:"items"`
-		Limit    int     `json:"limit"`
-		Next     string  `json:"next"`
-		Offset   int     `json:"offset"`
-		Previous string  `json:"previous"`
-		Total    int     `json:"total"`
-	} `json:"albums"`
-	Playlists struct {
-		Href     string     `json:"href"`
-		Items    []Playlist `json:"items"`
-		Limit    int        `json:"limit"`
-		Next     string     `json:"next"`
-		Offset   int        `json:"offset"`
-		Previous string     `json:"previous"`
-		Total    int        `json:"total"`
-	} `json:"playlists"`
+	return u.sendRequest(ctx, method, reqURL)
 }
 
 // Check if a User is following a set of artists
 // Returns a list of booleans corresponding to whether that artist in the
 // parameter list is followed by the User
-func (u *User) DoesFollowArtists(q Query, i ...interface{}) ([]bool, bool) {
-	ok := true
-	uris := make([]string, 0)
-
-	for _, item := range i {
-		switch v := item.(type) {
-		case string:
-			bytes, searchErr := q.search(v, "artist")
-			if searchErr != nil {
-				ok = false
-			}
-
-			searchResult := searchResult{}
-			jsonErr := json.Unmarshal(bytes, &searchResult)
-
-			if jsonErr != nil {
-				log.Fatal("JSON Error:", jsonErr)
-			}
-
-			if searchResult.Artists.Total > 0 {
-				playlist := searchResult.Artists.Items[0]
-				uris = append(uris, playlist.ID)
-			}
-		case Artist:
-			uris = append(uris, v.ID)
-		// Invalid Type
-		default:
-			ok = false
-		}
+func (u *User) DoesFollowArtists(q Query, i ...interface{}) ([]bool, error) {
+	return u.DoesFollowArtistsContext(context.Background(), q, i...)
+}
 
-		if !ok {
-			return make([]bool, 0), ok
-		}
-	}
+// DoesFollowArtistsContext is DoesFollowArtists with a context
+func (u *User) DoesFollowArtistsContext(ctx context.Context, q Query, i ...interface{}) (items []bool, err error) {
+	ctx, span := u.engine.startCall(ctx, "DoesFollowArtists")
+	defer func() { span.endItems(err, len(items)) }()
 
-	reqURL := u.baseURL + "me/following/contains?ids="
-	for _, uri := range uris {
-		reqURL += uri + ","
+	if err := u.checkScopes("DoesFollowArtists"); err != nil {
+		return nil, err
+	}
+	uris, err := resolveAll(ctx, q.artistID, i)
+	if err != nil {
+		return make([]bool, 0), err
 	}
-	reqURL = reqURL[:len(reqURL)-1]
+
+	reqURL := u.baseURL + "me/following/contains?ids=" + strings.Join(uris, ",")
 	reqURL += "&type=artist"
 
 	bools := make([]bool, 0)
-	ok = u.sendGetRequest(reqURL, &bools) && ok
+	err = u.sendGetRequest(ctx, reqURL, &bools)
 
-	return bools, ok
+	return bools, err
 }
 
 // Check if a User has saved a set of tracks
 // Returns a list of booleans corresponding to whether that track in the
 // parameter list is followed by the User
-func (u *User) HasSavedTracks(q Query, i ...interface{}) ([]bool, bool) {
-	ok := true
-	uris := make([]string, 0)
-
-	for _, item := range i {
-		switch v := item.(type) {
-		case string:
-			bytes, searchErr := q.search(v, "track")
-			if searchErr != nil {
-				ok = false
-			}
-
-			searchResult := searchResult{}
-			jsonErr := json.Unmarshal(bytes, &searchResult)
-
-			if jsonErr != nil {
-				log.Fatal("JSON Error:", jsonErr)
-			}
-
-			if searchResult.Tracks.Total > 0 {
-				playlist := searchResult.Tracks.Items[0]
-				uris = append(uris, playlist.ID)
-			}
-		case Track:
-			uris = append(uris, v.ID)
-		// Invalid Type
-		default:
-			ok = false
-		}
+func (u *User) HasSavedTracks(q Query, i ...interface{}) ([]bool, error) {
+	return u.HasSavedTracksContext(context.Background(), q, i...)
+}
 
-		if !ok {
-			return make([]bool, 0), ok
-		}
-	}
+// HasSavedTracksContext is HasSavedTracks with a context
+func (u *User) HasSavedTracksContext(ctx context.Context, q Query, i ...interface{}) (items []bool, err error) {
+	ctx, span := u.engine.startCall(ctx, "HasSavedTracks")
+	defer func() { span.endItems(err, len(items)) }()
 
-	reqURL := u.baseURL + "me/tracks/contains?ids="
-	for _, uri := range uris {
-		reqURL += uri + ","
+	if err := u.checkScopes("HasSavedTracks"); err != nil {
+		return nil, err
+	}
+	uris, err := resolveAll(ctx, q.trackID, i)
+	if err != nil {
+		return make([]bool, 0), err
 	}
-	reqURL = reqURL[:len(reqURL)-1]
+
+	reqURL := u.baseURL + "me/tracks/contains?ids=" + strings.Join(uris, ",")
 
 	bools := make([]bool, 0)
-	ok = u.sendGetRequest(reqURL, &bools) && ok
+	err = u.sendGetRequest(ctx, reqURL, &bools)
 
-	return bools, ok
+	return bools, err
 }
 
 // Check if a User has saved a set of albums
 // Returns a list of booleans corresponding to whether that album in the
 // parameter list is followed by the User
-func (u *User) HasSavedAlbums(q Query, i ...interface{}) ([]bool, bool) {
-	ok := true
-	uris := make([]string, 0)
-
-	for _, item := range i {
-		switch v := item.(type) {
-		case string:
-			bytes, searchErr := q.search(v, "album")
-			if searchErr != nil {
-				ok = false
-			}
-
-			searchResult := searchResult{}
-			jsonErr := json.Unmarshal(bytes, &searchResult)
-
-			if jsonErr != nil {
-				log.Fatal("JSON Error:", jsonErr)
-			}
-
-			if searchResult.Albums.Total > 0 {
-				playlist := searchResult.Albums.Items[0]
-				uris = append(uris, playlist.ID)
-			}
-		case Album:
-			uris = append(uris, v.ID)
-		// Invalid Type
-		default:
-			ok = false
-		}
+func (u *User) HasSavedAlbums(q Query, i ...interface{}) ([]bool, error) {
+	return u.HasSavedAlbumsContext(context.Background(), q, i...)
+}
 
-		if !ok {
-			return make([]bool, 0), ok
-		}
-	}
+// HasSavedAlbumsContext is HasSavedAlbums with a context
+func (u *User) HasSavedAlbumsContext(ctx context.Context, q Query, i ...interface{}) (items []bool, err error) {
+	ctx, span := u.engine.startCall(ctx, "HasSavedAlbums")
+	defer func() { span.endItems(err, len(items)) }()
 
-	reqURL := u.baseURL + "me/albums/contains?ids="
-	for _, uri := range uris {
-		reqURL += uri + ","
+	if err := u.checkScopes("HasSavedAlbums"); err != nil {
+		return nil, err
 	}
-	reqURL = reqURL[:len(reqURL)-1]
+	uris, err := resolveAll(ctx, q.albumID, i)
+	if err != nil {
+		return make([]bool, 0), err
+	}
+
+	reqURL := u.baseURL + "me/albums/contains?ids=" + strings.Join(uris, ",")
 
 	bools := make([]bool, 0)
-	ok = u.sendGetRequest(reqURL, &bools) && ok
+	err = u.sendGetRequest(ctx, reqURL, &bools)
 
-	return bools, ok
+	return bools, err
 }
@@ -0,0 +1,63 @@
+package spotigo_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+)
+
+func TestFollowUsers(t *testing.T) {
+	srv := newServer(t)
+	for _, id := range []string{"alice", "bob", "carol"} {
+		srv.AddUser(spotigo.Profile{ID: id})
+	}
+	q := newQuery(t, srv)
+	u := newUser(t, srv)
+
+	err := u.FollowUsers(q, "alice", "spotify:user:bob", spotigo.Profile{ID: "carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := srv.Library(spotigotest.DefaultUserID).Users, []string{"alice", "bob", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("following %v, want %v", got, want)
+	}
+	for _, r := range srv.Requests() {
+		if strings.HasPrefix(r, "GET /v1/search") {
+			t.Errorf("users were searched for: %s", r)
+		}
+	}
+
+	if err := u.UnfollowUsers(q, "https://open.spotify.com/user/bob"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := srv.Library(spotigotest.DefaultUserID).Users, []string{"alice", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("following %v, want %v", got, want)
+	}
+
+	if err := u.FollowUsers(q, "spotify:artist:0TnOYISbd1XYRBk9myaseg"); !errors.Is(err, spotigo.ErrInvalidInput) {
+		t.Errorf("following an artist URI: got %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestSaveTracksResolvesBareIDs(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("4uLU6hMCjMI75M1A2tKUQC", "Disco Man", "Remi Wolf", 50), newTrack("t2", "Juno", "Remi Wolf", 50))
+	q := newQuery(t, srv)
+	u := newUser(t, srv)
+
+	// A 22-character ID is used as is; a shorter base-62 word is a name
+	if err := u.SaveTracks(q, " 4uLU6hMCjMI75M1A2tKUQC ", "Juno"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRequests(srv, "GET /v1/search"); n != 1 {
+		t.Errorf("%d searches, want 1 for Juno", n)
+	}
+	saved := srv.Library(spotigotest.DefaultUserID).Tracks
+	if len(saved) != 2 || !strings.Contains(strings.Join(saved, ","), "4uLU6hMCjMI75M1A2tKUQC") || !strings.Contains(strings.Join(saved, ","), "t2") {
+		t.Errorf("saved %v, want both tracks", saved)
+	}
+}
@@ -1,5 +1,7 @@
 package spotigo
 
+import "strings"
+
 // Scopes let you specify exactly which types of data your application wants to access.
 // The set of scopes you pass in your authentication request determines what access the
 // permissions the user is asked to grant.
@@ -53,3 +55,102 @@ const (
 	// ScopeStreaming seeks permission to play music and control playback on your other devices.
 	ScopeStreaming = "streaming"
 )
+
+// Scopes each User method needs, by method name; the Context variant of a
+// method needs the same scopes, and methods not listed need none
+var methodScopes = map[string][]string{
+	// Library
+	"GetSavedTracks":        {ScopeUserLibraryRead},
+	"GetNumSavedTracks":     {ScopeUserLibraryRead},
+	"GetSavedAlbums":        {ScopeUserLibraryRead},
+	"GetNumSavedAlbums":     {ScopeUserLibraryRead},
+	"HasSavedTracks":        {ScopeUserLibraryRead},
+	"HasSavedAlbums":        {ScopeUserLibraryRead},
+	"SaveTracks":            {ScopeUserLibraryModify},
+	"UnsaveTracks":          {ScopeUserLibraryModify},
+	"SaveAlbums":            {ScopeUserLibraryModify},
+	"UnsaveAlbums":          {ScopeUserLibraryModify},
+	"SavePlaylists":         {ScopePlaylistModifyPublic},
+	"UnsavePlaylists":       {ScopePlaylistModifyPublic},
+	"GetSavedPlaylists":     {ScopePlaylistReadPrivate},
+	"GetNumSavedPlaylists":  {ScopePlaylistReadPrivate},
+	"GetFollowedArtists":    {ScopeUserFollowRead},
+	"GetNumFollowedArtists": {ScopeUserFollowRead},
+	"DoesFollowArtists":     {ScopeUserFollowRead},
+	"FollowArtists":         {ScopeUserFollowModify},
+	"UnfollowArtists":       {ScopeUserFollowModify},
+	"FollowUsers":           {ScopeUserFollowModify},
+	"UnfollowUsers":         {ScopeUserFollowModify},
+
+	// Playback state
+	"GetPlaybackDevices":       {ScopeUserReadPlaybackState},
+	"GetCurrentlyPlayingTrack": {ScopeUserReadCurrentlyPlaying},
+	"IsShuffling":              {ScopeUserReadCurrentlyPlaying},
+	"IsPlaying":                {ScopeUserReadCurrentlyPlaying},
+	"CurrentTrackProgress":     {ScopeUserReadCurrentlyPlaying},
+	"ActiveDevice":             {ScopeUserReadCurrentlyPlaying},
+	"CurrentRepeatState":       {ScopeUserReadCurrentlyPlaying},
+
+	// Playback control
+	"TransferPlayback": {ScopeUserModifyPlaybackState},
+	"Pause":            {ScopeUserModifyPlaybackState},
+	"Play":             {ScopeUserModifyPlaybackState},
+	"PlayTrack":        {ScopeUserModifyPlaybackState},
+	"SetVolume":        {ScopeUserModifyPlaybackState},
+	"SetRepeat":        {ScopeUserModifyPlaybackState},
+	"SetShuffle":       {ScopeUserModifyPlaybackState},
+	"SkipToNext":       {ScopeUserModifyPlaybackState},
+	"SkipToPrev":       {ScopeUserModifyPlaybackState},
+	"AddTrackToQueue":  {ScopeUserModifyPlaybackState},
+	"SeekToPosition":   {ScopeUserModifyPlaybackState},
+
+	// Profile
+	"GetCurrentProfile": {ScopeUserReadPrivate},
+}
+
+// Broader scopes that also grant a scope
+// The currently-playing endpoint accepts either of the playback read scopes
+var impliedBy = map[string][]string{
+	ScopeUserReadCurrentlyPlaying: {ScopeUserReadPlaybackState},
+}
+
+// Get the scopes a User method needs, e.g. RequiredScopes("GetSavedTracks")
+// Returns nil for methods that need no scopes
+func RequiredScopes(method string) []string {
+	return append([]string(nil), methodScopes[strings.TrimSuffix(method, "Context")]...)
+}
+
+// Check that the User granted the scopes method needs, before calling the API
+// Nothing is checked when the token doesn't say which scopes were granted
+func (u *User) checkScopes(method string) error {
+	granted := u.tokens.grantedScopes()
+	if len(granted) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, s := range methodScopes[method] {
+		if !hasScope(granted, s) {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingScopeError{Method: method, Scopes: missing}
+	}
+	return nil
+}
+
+// Whether granted includes s, or a scope that implies it
+func hasScope(granted []string, s string) bool {
+	for _, g := range granted {
+		if g == s {
+			return true
+		}
+		for _, broader := range impliedBy[s] {
+			if g == broader {
+				return true
+			}
+		}
+	}
+	return false
+}
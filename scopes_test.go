@@ -0,0 +1,162 @@
+package spotigo_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+	"golang.org/x/oauth2"
+)
+
+func TestRequiredScopes(t *testing.T) {
+	tests := []struct {
+		method string
+		want   []string
+	}{
+		{"GetSavedTracks", []string{spotigo.ScopeUserLibraryRead}},
+		{"GetSavedTracksContext", []string{spotigo.ScopeUserLibraryRead}},
+		{"GetSavedPlaylistsContext", []string{spotigo.ScopePlaylistReadPrivate}},
+		{"GetCurrentProfile", []string{spotigo.ScopeUserReadPrivate}},
+		{"Pause", []string{spotigo.ScopeUserModifyPlaybackState}},
+		{"PlayContext", []string{spotigo.ScopeUserModifyPlaybackState}},
+		{"GetTrackAudioFeatures", nil},
+		{"NoSuchMethod", nil},
+	}
+	for _, tt := range tests {
+		if got := spotigo.RequiredScopes(tt.method); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RequiredScopes(%q) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+
+	// The result is a copy
+	spotigo.RequiredScopes("GetSavedTracks")[0] = "changed"
+	if got := spotigo.RequiredScopes("GetSavedTracks"); got[0] != spotigo.ScopeUserLibraryRead {
+		t.Errorf("RequiredScopes shares its result: %v", got)
+	}
+}
+
+// Create a User from a token granting scopes; nil scopes leaves the grant unknown
+func userWithScopes(t *testing.T, srv *spotigotest.Server, scopes []string) *spotigo.User {
+	t.Helper()
+	tok := &oauth2.Token{AccessToken: srv.UserToken(spotigotest.DefaultUserID, scopes...), TokenType: "Bearer"}
+	if scopes != nil {
+		tok = tok.WithExtra(map[string]interface{}{"scope": strings.Join(scopes, " ")})
+	}
+	u, err := spotigo.NewUserFromToken("id", "secret", tok, testOptions(srv)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestCheckScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		call    func(*spotigo.User) error
+		missing []string
+	}{
+		{
+			name:    "granted",
+			granted: []string{spotigo.ScopeUserLibraryRead},
+			call:    func(u *spotigo.User) error { _, err := u.GetNumSavedTracks(); return err },
+		},
+		{
+			name:    "missing",
+			granted: []string{spotigo.ScopeUserLibraryModify},
+			call:    func(u *spotigo.User) error { _, err := u.GetNumSavedTracks(); return err },
+			missing: []string{spotigo.ScopeUserLibraryRead},
+		},
+		{
+			name:    "implied by playback state",
+			granted: []string{spotigo.ScopeUserReadPlaybackState},
+			call:    func(u *spotigo.User) error { _, err := u.IsShuffling(); return err },
+		},
+		{
+			name:    "not implied the other way",
+			granted: []string{spotigo.ScopeUserReadCurrentlyPlaying},
+			call:    func(u *spotigo.User) error { _, err := u.GetPlaybackDevices(); return err },
+			missing: []string{spotigo.ScopeUserReadPlaybackState},
+		},
+		{
+			name:    "profile",
+			granted: []string{spotigo.ScopeUserReadEmail},
+			call:    func(u *spotigo.User) error { _, err := u.GetCurrentProfile(); return err },
+			missing: []string{spotigo.ScopeUserReadPrivate},
+		},
+		{
+			name:    "grant unknown, so nothing is checked",
+			granted: nil,
+			call:    func(u *spotigo.User) error { _, err := u.GetNumSavedTracks(); return err },
+		},
+	}
+	for _, tt := range tests {
+		srv := newServer(t)
+		u := userWithScopes(t, srv, tt.granted)
+		err := tt.call(u)
+
+		var scopeErr *spotigo.MissingScopeError
+		if tt.missing == nil {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.As(err, &scopeErr) || !errors.Is(err, spotigo.ErrMissingScope) {
+			t.Errorf("%s: got %v, want a MissingScopeError", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(scopeErr.Scopes, tt.missing) {
+			t.Errorf("%s: missing %v, want %v", tt.name, scopeErr.Scopes, tt.missing)
+		}
+		if n := countRequests(srv, "GET /v1/"); n != 0 {
+			t.Errorf("%s: %d requests sent despite the missing scope", tt.name, n)
+		}
+	}
+}
+
+func TestPauseWithoutReadScope(t *testing.T) {
+	srv := newServer(t)
+	srv.AddDevice(spotigotest.DefaultUserID, spotigo.Device{ID: "d1", Active: true})
+	u := userWithScopes(t, srv, []string{spotigo.ScopeUserModifyPlaybackState})
+
+	// Play and Pause don't need to read the playback state first
+	if err := u.Play(); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRequests(srv, "GET /v1/me/player"); n != 0 {
+		t.Errorf("%d playback state requests, want 0", n)
+	}
+	if srv.Player(spotigotest.DefaultUserID).IsPlaying {
+		t.Error("still playing after Pause")
+	}
+}
+
+func TestReauthorize(t *testing.T) {
+	srv := newServer(t)
+	u := newUser(t, srv, spotigo.WithScopes(spotigo.ScopeUserLibraryRead))
+	if _, err := u.GetCurrentProfile(); !errors.Is(err, spotigo.ErrMissingScope) {
+		t.Fatalf("got %v, want ErrMissingScope", err)
+	}
+
+	wider, err := u.Reauthorize(spotigo.ScopeUserReadPrivate, spotigo.ScopeUserLibraryRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{spotigo.ScopeUserLibraryRead, spotigo.ScopeUserReadPrivate}
+	if got := wider.GetScopes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetScopes() = %v, want %v", got, want)
+	}
+	if _, err := wider.GetCurrentProfile(); err != nil {
+		t.Error(err)
+	}
+	if _, err := u.GetCurrentProfile(); !errors.Is(err, spotigo.ErrMissingScope) {
+		t.Errorf("old User: got %v, want ErrMissingScope", err)
+	}
+}
@@ -0,0 +1,314 @@
+package spotigo
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SearchType selects what a search returns; combine types with |
+type SearchType int
+
+const (
+	SearchTypeTrack SearchType = 1 << iota
+	SearchTypeAlbum
+	SearchTypeArtist
+	SearchTypePlaylist
+	SearchTypeShow
+	SearchTypeEpisode
+	SearchTypeAudiobook
+)
+
+// Names of the search types, as sent in the type parameter, in the order of
+// the SearchType bits
+var searchTypeNames = []string{"track", "album", "artist", "playlist", "show", "episode", "audiobook"}
+
+// The type parameter for t, e.g. "track,album"
+func (t SearchType) String() string {
+	names := make([]string, 0)
+	for i, name := range searchTypeNames {
+		if t&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Maximum number of items per page Spotify returns for each type
+const maxSearchLimit = 50
+
+// SearchOption configures a search
+type SearchOption func(*searchParams)
+
+// Parameters of a search, kept with its result for paging
+type searchParams struct {
+	limit           int
+	offset          int
+	market          string
+	includeExternal bool
+}
+
+// Return up to limit items of each type, 1 to 50 (default 20)
+func SearchLimit(limit int) SearchOption {
+	return func(p *searchParams) {
+		p.limit = limit
+	}
+}
+
+// Skip the first offset items of each type (default 0)
+func SearchOffset(offset int) SearchOption {
+	return func(p *searchParams) {
+		p.offset = offset
+	}
+}
+
+// Only return items available in market, an ISO 3166-1 alpha-2 country code,
+// or "from_token" for the market of the User's account
+func SearchMarket(market string) SearchOption {
+	return func(p *searchParams) {
+		p.market = market
+	}
+}
+
+// Include externally hosted audio, such as podcast episodes hosted elsewhere
+func SearchIncludeExternalAudio() SearchOption {
+	return func(p *searchParams) {
+		p.includeExternal = true
+	}
+}
+
+// Paging struct- the paging information of one type of search results
+type Paging struct {
+	Href     string `json:"href"`
+	Limit    int    `json:"limit"`
+	Next     string `json:"next"`
+	Offset   int    `json:"offset"`
+	Previous string `json:"previous"`
+	Total    int    `json:"total"`
+}
+
+// A page of Tracks
+type TrackPage struct {
+	Paging
+	Items []Track `json:"items"`
+}
+
+// A page of Albums
+type AlbumPage struct {
+	Paging
+	Items []Album `json:"items"`
+}
+
+// A page of Artists
+type ArtistPage struct {
+	Paging
+	Items []Artist `json:"items"`
+}
+
+// A page of Playlists
+type PlaylistPage struct {
+	Paging
+	Items []Playlist `json:"items"`
+}
+
+// A page of Shows
+type ShowPage struct {
+	Paging
+	Items []Show `json:"items"`
+}
+
+// A page of Episodes
+type EpisodePage struct {
+	Paging
+	Items []Episode `json:"items"`
+}
+
+// A page of Audiobooks
+type AudiobookPage struct {
+	Paging
+	Items []Audiobook `json:"items"`
+}
+
+// SearchResult struct- one page of search results for each type searched for
+// Pages of types that weren't searched for are empty
+type SearchResult struct {
+	Tracks     TrackPage     `json:"tracks"`
+	Albums     AlbumPage     `json:"albums"`
+	Artists    ArtistPage    `json:"artists"`
+	Playlists  PlaylistPage  `json:"playlists"`
+	Shows      ShowPage      `json:"shows"`
+	Episodes   EpisodePage   `json:"episodes"`
+	Audiobooks AudiobookPage `json:"audiobooks"`
+
+	// The search that produced this page, for NextPage and PreviousPage
+	query  string
+	types  SearchType
+	params searchParams
+}
+
+// Paging information of each type searched for
+func (r *SearchResult) pages() map[SearchType]*Paging {
+	all := map[SearchType]*Paging{
+		SearchTypeTrack:     &r.Tracks.Paging,
+		SearchTypeAlbum:     &r.Albums.Paging,
+		SearchTypeArtist:    &r.Artists.Paging,
+		SearchTypePlaylist:  &r.Playlists.Paging,
+		SearchTypeShow:      &r.Shows.Paging,
+		SearchTypeEpisode:   &r.Episodes.Paging,
+		SearchTypeAudiobook: &r.Audiobooks.Paging,
+	}
+	for t := range all {
+		if r.types&t == 0 {
+			delete(all, t)
+		}
+	}
+	return all
+}
+
+// Types that have a next (or previous) page
+func (r *SearchResult) typesWith(next bool) SearchType {
+	var types SearchType
+	for t, p := range r.pages() {
+		if (next && p.Next != "") || (!next && p.Previous != "") {
+			types |= t
+		}
+	}
+	return types
+}
+
+// Number of items on the page, of every type, or 0 for a nil result
+func (r *SearchResult) count() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.Tracks.Items) + len(r.Albums.Items) + len(r.Artists.Items) + len(r.Playlists.Items) +
+		len(r.Shows.Items) + len(r.Episodes.Items) + len(r.Audiobooks.Items)
+}
+
+// Whether any type searched for has more results after this page
+func (r *SearchResult) HasNext() bool {
+	return r.typesWith(true) != 0
+}
+
+// Whether any type searched for has results before this page
+func (r *SearchResult) HasPrevious() bool {
+	return r.typesWith(false) != 0
+}
+
+// Search the Spotify catalog for items of the given types
+// Combine types with |, e.g. SearchTypeTrack|SearchTypeAlbum
+// The query is a string search query; for the user to reliably get the
+// track they're looking for, they need to include as much information in this
+// string as possible. Misspelled or incomplete inputs will return a result,
+// but the more information included, the more likely the result will be as intended
+// Bad input example: "disco"
+// Good input example: "Disco Man Remi Wolf"
+func (q Query) Search(query string, types SearchType, opts ...SearchOption) (*SearchResult, error) {
+	return q.SearchContext(context.Background(), query, types, opts...)
+}
+
+// SearchContext is Search with a context
+func (q Query) SearchContext(ctx context.Context, query string, types SearchType, opts ...SearchOption) (page *SearchResult, err error) {
+	ctx, span := q.engine.startCall(ctx, "Search")
+	defer func() { span.endItems(err, page.count()) }()
+
+	var p searchParams
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return q.searchPage(ctx, query, types, p)
+}
+
+// Get the next page of results for each type that has one
+// Types without more results are left out of the new page
+func (q Query) NextPage(r *SearchResult) (*SearchResult, error) {
+	return q.NextPageContext(context.Background(), r)
+}
+
+// NextPageContext is NextPage with a context
+func (q Query) NextPageContext(ctx context.Context, r *SearchResult) (page *SearchResult, err error) {
+	ctx, span := q.engine.startCall(ctx, "NextPage")
+	defer func() { span.endItems(err, page.count()) }()
+
+	types := r.typesWith(true)
+	if types == 0 {
+		return nil, invalidInput("no next page")
+	}
+	p := r.params
+	p.offset += r.limit()
+	return q.searchPage(ctx, r.query, types, p)
+}
+
+// Get the previous page of results for each type that has one
+func (q Query) PreviousPage(r *SearchResult) (*SearchResult, error) {
+	return q.PreviousPageContext(context.Background(), r)
+}
+
+// PreviousPageContext is PreviousPage with a context
+func (q Query) PreviousPageContext(ctx context.Context, r *SearchResult) (page *SearchResult, err error) {
+	ctx, span := q.engine.startCall(ctx, "PreviousPage")
+	defer func() { span.endItems(err, page.count()) }()
+
+	types := r.typesWith(false)
+	if types == 0 {
+		return nil, invalidInput("no previous page")
+	}
+	p := r.params
+	p.offset -= r.limit()
+	if p.offset < 0 {
+		p.offset = 0
+	}
+	return q.searchPage(ctx, r.query, types, p)
+}
+
+// Page size used for a result: the requested limit, or the one Spotify applied
+func (r *SearchResult) limit() int {
+	if r.params.limit > 0 {
+		return r.params.limit
+	}
+	for _, p := range r.pages() {
+		if p.Limit > 0 {
+			return p.Limit
+		}
+	}
+	return 20
+}
+
+// Make one search request
+func (q Query) searchPage(ctx context.Context, query string, types SearchType, p searchParams) (*SearchResult, error) {
+	if query == "" {
+		return nil, invalidInput("empty search query")
+	}
+	if types <= 0 || types >= 1<<len(searchTypeNames) {
+		return nil, invalidInput("bad search type %d", int(types))
+	}
+	if p.limit < 0 || p.limit > maxSearchLimit {
+		return nil, invalidInput("search limit %d out of range 1 to %d", p.limit, maxSearchLimit)
+	}
+	if p.offset < 0 {
+		return nil, invalidInput("negative search offset %d", p.offset)
+	}
+
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("type", types.String())
+	if p.limit > 0 {
+		params.Set("limit", strconv.Itoa(p.limit))
+	}
+	if p.offset > 0 {
+		params.Set("offset", strconv.Itoa(p.offset))
+	}
+	if p.market != "" {
+		params.Set("market", p.market)
+	}
+	if p.includeExternal {
+		params.Set("include_external", "audio")
+	}
+
+	result := &SearchResult{query: query, types: types, params: p}
+	if err := q.get(ctx, q.baseURL+"search?"+params.Encode(), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package spotigo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SearchQuery builds a search query from Spotify's field filters, which match
+// more reliably than a plain text query
+// Pass its String to Search or any Get*ByName method, or pass it as is
+// wherever a method accepts a search query or a struct:
+//
+//	q.GetTrackByName(spotigo.SearchQuery{Track: "Disco Man", Artist: "Remi Wolf"}.String())
+//
+// See: https://developer.spotify.com/documentation/web-api/reference/search
+type SearchQuery struct {
+	// Plain text matched against every field, as in a plain query
+	Keywords string
+
+	Artist string
+	Album  string
+	Track  string
+	// Tracks and artists only
+	Genre string
+	// Tracks only: International Standard Recording Code
+	ISRC string
+	// Albums only: Universal Product Code
+	UPC string
+
+	// Release year, or the first year of a range ending at YearTo
+	Year int
+	// Last year of a range starting at Year; 0 for a single year
+	YearTo int
+
+	// Albums only: released in the past two weeks (tag:new)
+	New bool
+	// Albums only: in the lowest 10% of popularity (tag:hipster)
+	Hipster bool
+}
+
+// The query string sent to Spotify, e.g. `disco artist:"Remi Wolf" year:2019-2021`
+func (sq SearchQuery) String() string {
+	terms := make([]string, 0)
+	if k := strings.TrimSpace(sq.Keywords); k != "" {
+		terms = append(terms, k)
+	}
+
+	filters := []struct{ field, value string }{
+		{"artist", sq.Artist},
+		{"album", sq.Album},
+		{"track", sq.Track},
+		{"genre", sq.Genre},
+		{"isrc", sq.ISRC},
+		{"upc", sq.UPC},
+	}
+	for _, f := range filters {
+		if v := quoteFilter(f.value); v != "" {
+			terms = append(terms, f.field+":"+v)
+		}
+	}
+
+	if sq.Year > 0 {
+		from, to := sq.Year, sq.YearTo
+		if to != 0 && to < from {
+			from, to = to, from
+		}
+		year := strconv.Itoa(from)
+		if to != 0 && to != from {
+			year += "-" + strconv.Itoa(to)
+		}
+		terms = append(terms, "year:"+year)
+	}
+
+	if sq.New {
+		terms = append(terms, "tag:new")
+	}
+	if sq.Hipster {
+		terms = append(terms, "tag:hipster")
+	}
+	return strings.Join(terms, " ")
+}
+
+// Format a filter value, quoting it if it has more than one word
+// Spotify has no escape for quotes inside a value, so they are dropped
+func quoteFilter(value string) string {
+	value = strings.Join(strings.Fields(strings.ReplaceAll(value, `"`, "")), " ")
+	if strings.ContainsAny(value, " :") {
+		return `"` + value + `"`
+	}
+	return value
+}
@@ -0,0 +1,51 @@
+package spotigo_test
+
+import (
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+)
+
+func TestSearchQueryString(t *testing.T) {
+	tests := []struct {
+		sq   spotigo.SearchQuery
+		want string
+	}{
+		{spotigo.SearchQuery{}, ""},
+		{spotigo.SearchQuery{Keywords: "  disco  "}, "disco"},
+		{spotigo.SearchQuery{Track: "Disco Man", Artist: "Remi Wolf"}, `artist:"Remi Wolf" track:"Disco Man"`},
+		{spotigo.SearchQuery{Keywords: "disco", Artist: "Remi Wolf", Year: 2019, YearTo: 2021}, `disco artist:"Remi Wolf" year:2019-2021`},
+		{spotigo.SearchQuery{Album: "Juno", Year: 2021}, "album:Juno year:2021"},
+		{spotigo.SearchQuery{Year: 2021, YearTo: 2019}, "year:2019-2021"},
+		{spotigo.SearchQuery{Year: 2020, YearTo: 2020}, "year:2020"},
+		{spotigo.SearchQuery{YearTo: 2020}, ""},
+		{spotigo.SearchQuery{Genre: "indie pop", ISRC: "USUM71900001"}, `genre:"indie pop" isrc:USUM71900001`},
+		{spotigo.SearchQuery{UPC: "00602577", New: true, Hipster: true}, "upc:00602577 tag:new tag:hipster"},
+		{spotigo.SearchQuery{Track: `Say "Hi"`}, `track:"Say Hi"`},
+		{spotigo.SearchQuery{Track: "  Re:  Stacks "}, `track:"Re: Stacks"`},
+		{spotigo.SearchQuery{Album: "Time:Out"}, `album:"Time:Out"`},
+		{spotigo.SearchQuery{Artist: `""`}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.sq.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.sq, got, tt.want)
+		}
+	}
+}
+
+func TestSearchQueryFiltersThroughServer(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(
+		newTrack("t1", "Disco Man", "Remi Wolf", 40),
+		newTrack("t2", "Disco Man", "Cover Band", 90),
+	)
+	q := newQuery(t, srv)
+
+	track, err := q.GetTrackByName(spotigo.SearchQuery{Track: "Disco Man", Artist: "Remi Wolf"}.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.ID != "t1" {
+		t.Errorf("got track %s by %s, want t1", track.ID, track.Artists[0].Name)
+	}
+}
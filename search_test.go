@@ -0,0 +1,69 @@
+package spotigo_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+)
+
+func TestSearchPaging(t *testing.T) {
+	srv := newServer(t)
+	for i := 0; i < 7; i++ {
+		srv.AddTrack(newTrack(fmt.Sprintf("t%d", i), fmt.Sprintf("Disco %d", i), "Remi Wolf", 50))
+	}
+	for i := 0; i < 2; i++ {
+		srv.AddAlbum(spotigo.Album{ID: fmt.Sprintf("a%d", i), Name: fmt.Sprintf("Disco Album %d", i)})
+	}
+	q := newQuery(t, srv)
+
+	page, err := q.Search("Disco", spotigo.SearchTypeTrack|spotigo.SearchTypeAlbum, spotigo.SearchLimit(3), spotigo.SearchMarket("SE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.HasPrevious() || len(page.Albums.Items) != 2 {
+		t.Fatalf("first page: previous %v, %d albums", page.HasPrevious(), len(page.Albums.Items))
+	}
+
+	// Walk forward; albums have no second page, so only tracks are fetched
+	forward := []string{fmt.Sprint(trackIDs(page.Tracks.Items))}
+	for page.HasNext() {
+		if page, err = q.NextPage(page); err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Albums.Items) != 0 {
+			t.Errorf("page %d has albums", len(forward))
+		}
+		forward = append(forward, fmt.Sprint(trackIDs(page.Tracks.Items)))
+	}
+	if want := "[[t0 t1 t2] [t3 t4 t5] [t6]]"; fmt.Sprint(forward) != want {
+		t.Errorf("pages %v, want %s", forward, want)
+	}
+	if _, err := q.NextPage(page); !errors.Is(err, spotigo.ErrInvalidInput) {
+		t.Errorf("NextPage of the last page: got %v, want ErrInvalidInput", err)
+	}
+
+	// And back again
+	backward := []string{forward[len(forward)-1]}
+	for page.HasPrevious() {
+		if page, err = q.PreviousPage(page); err != nil {
+			t.Fatal(err)
+		}
+		backward = append(backward, fmt.Sprint(trackIDs(page.Tracks.Items)))
+	}
+	if want := "[[t6] [t3 t4 t5] [t0 t1 t2]]"; fmt.Sprint(backward) != want {
+		t.Errorf("pages back %v, want %s", backward, want)
+	}
+	if _, err := q.PreviousPage(page); !errors.Is(err, spotigo.ErrInvalidInput) {
+		t.Errorf("PreviousPage of the first page: got %v, want ErrInvalidInput", err)
+	}
+
+	// Every page keeps the limit and market
+	for _, r := range srv.Requests() {
+		if strings.HasPrefix(r, "GET /v1/search") && (!strings.Contains(r, "limit=3") || !strings.Contains(r, "market=SE")) {
+			t.Errorf("request %s lost the limit or market", r)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package spotigo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Most IDs Spotify accepts in one request for several items of each type
+const (
+	maxTracksPerRequest  = 50
+	maxAlbumsPerRequest  = 20
+	maxArtistsPerRequest = 50
+)
+
+// Fetch several items of type typ by ID, URI or URL, max per request, from
+// the endpoint that returns them in one response
+// Returns each item's JSON and error, aligned with uris; items Spotify has
+// no record of get an ErrNotFound error
+func (q Query) getSeveral(ctx context.Context, typ EntityType, max int, uris []string) ([]json.RawMessage, []error) {
+	items := make([]json.RawMessage, len(uris))
+	errs := make([]error, len(uris))
+
+	// Positions in uris of the IDs in the next request
+	chunk := make([]int, 0, max)
+	ids := make([]string, len(uris))
+	flush := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		page := make([]string, len(chunk))
+		for j, i := range chunk {
+			page[j] = ids[i]
+		}
+
+		res := make(map[string][]json.RawMessage)
+		endpoint := string(typ) + "s"
+		err := q.get(ctx, q.baseURL+endpoint+"?ids="+url.QueryEscape(strings.Join(page, ",")), &res)
+		if err == nil && len(res[endpoint]) != len(chunk) {
+			err = fmt.Errorf("spotify: got %d %s for %d IDs", len(res[endpoint]), endpoint, len(chunk))
+		}
+		for j, i := range chunk {
+			switch {
+			case err != nil:
+				errs[i] = err
+			case string(res[endpoint][j]) == "null":
+				errs[i] = fmt.Errorf("%w: no %s with ID %q", ErrNotFound, typ, ids[i])
+			default:
+				items[i] = res[endpoint][j]
+			}
+		}
+		chunk = chunk[:0]
+	}
+
+	for i, uri := range uris {
+		if uri == "" {
+			errs[i] = invalidInput("empty URI")
+			continue
+		}
+		if ids[i], errs[i] = parseIDOf(uri, typ); errs[i] != nil {
+			continue
+		}
+		chunk = append(chunk, i)
+		if len(chunk) == max {
+			flush()
+		}
+	}
+	flush()
+	return items, errs
+}
+
+// Decode each fetched item into the element of results with the same index
+func decodeSeveral(items []json.RawMessage, errs []error, result func(i int) interface{}) error {
+	for i, item := range items {
+		if errs[i] == nil {
+			errs[i] = json.Unmarshal(item, result(i))
+		}
+	}
+	return batchError(errs)
+}
@@ -0,0 +1,121 @@
+package spotigo_test
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+)
+
+// Number of IDs in each request to endpoint, in order
+func chunkSizes(t *testing.T, srv *spotigotest.Server, endpoint string) []int {
+	t.Helper()
+	sizes := make([]int, 0)
+	for _, r := range srv.Requests() {
+		if !strings.HasPrefix(r, "GET /v1/"+endpoint+"?") {
+			continue
+		}
+		values, err := url.ParseQuery(r[strings.Index(r, "?")+1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(strings.Split(values.Get("ids"), ",")))
+	}
+	return sizes
+}
+
+func TestGetTracksByURIsChunks(t *testing.T) {
+	srv := newServer(t)
+	uris := make([]string, 120)
+	for i := range uris {
+		id := fmt.Sprintf("t%03d", i)
+		srv.AddTrack(newTrack(id, "Song "+id, "Remi Wolf", 50))
+		uris[i] = "spotify:track:" + id
+	}
+	// A missing track in the middle of a request, and two that are never sent
+	uris[60] = "spotify:track:missing"
+	uris[75] = ""
+	uris[80] = "spotify:album:t080"
+	q := newQuery(t, srv)
+
+	tracks, err := q.GetTracksByURIs(uris...)
+	var batch *spotigo.BatchError
+	if !errors.As(err, &batch) {
+		t.Fatalf("got %v, want a *BatchError", err)
+	}
+	if len(tracks) != len(uris) || len(batch.Errs) != len(uris) {
+		t.Fatalf("%d tracks and %d errors for %d URIs", len(tracks), len(batch.Errs), len(uris))
+	}
+	for i := range uris {
+		switch i {
+		case 60:
+			if !errors.Is(batch.Errs[i], spotigo.ErrNotFound) {
+				t.Errorf("Errs[%d] = %v, want ErrNotFound", i, batch.Errs[i])
+			}
+		case 75, 80:
+			if !errors.Is(batch.Errs[i], spotigo.ErrInvalidInput) {
+				t.Errorf("Errs[%d] = %v, want ErrInvalidInput", i, batch.Errs[i])
+			}
+		default:
+			if batch.Errs[i] != nil {
+				t.Errorf("Errs[%d] = %v", i, batch.Errs[i])
+			}
+			if want := fmt.Sprintf("t%03d", i); tracks[i].ID != want {
+				t.Errorf("tracks[%d] = %q, want %q", i, tracks[i].ID, want)
+			}
+			continue
+		}
+		if tracks[i].ID != "" {
+			t.Errorf("failed tracks[%d] = %q, want it empty", i, tracks[i].ID)
+		}
+	}
+
+	if got, want := fmt.Sprint(chunkSizes(t, srv, "tracks")), "[50 50 18]"; got != want {
+		t.Errorf("requests of %s IDs, want %s", got, want)
+	}
+}
+
+func TestGetAlbumsAndArtistsByURIsChunks(t *testing.T) {
+	srv := newServer(t)
+	albums := make([]string, 45)
+	for i := range albums {
+		albums[i] = fmt.Sprintf("a%03d", i)
+		srv.AddAlbum(spotigo.Album{ID: albums[i]})
+	}
+	artists := make([]string, 51)
+	for i := range artists {
+		artists[i] = fmt.Sprintf("r%03d", i)
+		srv.AddArtist(spotigo.Artist{ID: artists[i]})
+	}
+	q := newQuery(t, srv)
+
+	gotAlbums, err := q.GetAlbumsByURIs(albums...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotArtists, err := q.GetArtistsByURIs(artists...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, a := range gotAlbums {
+		if a.ID != albums[i] {
+			t.Errorf("albums[%d] = %q, want %q", i, a.ID, albums[i])
+		}
+	}
+	for i, a := range gotArtists {
+		if a.ID != artists[i] {
+			t.Errorf("artists[%d] = %q, want %q", i, a.ID, artists[i])
+		}
+	}
+
+	if got, want := fmt.Sprint(chunkSizes(t, srv, "albums")), "[20 20 5]"; got != want {
+		t.Errorf("album requests of %s IDs, want %s", got, want)
+	}
+	if got, want := fmt.Sprint(chunkSizes(t, srv, "artists")), "[50 1]"; got != want {
+		t.Errorf("artist requests of %s IDs, want %s", got, want)
+	}
+}
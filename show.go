@@ -0,0 +1,43 @@
+package spotigo
+
+// Show struct- maps to Spotify JSON response format by tag `json: "var_name"`
+// Struct generated by putting Spotify JSON data into JSON to Go struct generator at:
+// https://mholt.github.io/json-to-go/
+type Show struct {
+	AvailableMarkets []string `json:"available_markets"`
+	Copyrights       []struct {
+		Text string `json:"text"`
+		Type string `json:"type"`
+	} `json:"copyrights"`
+	Description     string `json:"description"`
+	HTMLDescription string `json:"html_description"`
+	Explicit        bool   `json:"explicit"`
+	ExternalUrls    struct {
+		Spotify string `json:"spotify"`
+	} `json:"external_urls"`
+	Href   string `json:"href"`
+	ID     string `json:"id"`
+	Images []struct {
+		Height int    `json:"height"`
+		URL    string `json:"url"`
+		Width  int    `json:"width"`
+	} `json:"images"`
+	IsExternallyHosted bool     `json:"is_externally_hosted"`
+	Languages          []string `json:"languages"`
+	MediaType          string   `json:"media_type"`
+	Name               string   `json:"name"`
+	Publisher          string   `json:"publisher"`
+	TotalEpisodes      int      `json:"total_episodes"`
+	Type               string   `json:"type"`
+	URI                string   `json:"uri"`
+}
+
+// Get Show Name
+func (s *Show) GetName() string {
+	return s.Name
+}
+
+// Get Show URI
+func (s *Show) GetURI() string {
+	return uriOf(EntityShow, s.ID)
+}
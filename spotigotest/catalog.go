@@ -0,0 +1,334 @@
+package spotigotest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adamgamba/spotigo"
+)
+
+// Maximum number of playlist tracks embedded in a playlist object
+const playlistTracksLimit = 100
+
+// catalog struct- the content every user can look up
+type catalog struct {
+	tracks     map[string]spotigo.Track
+	albums     map[string]spotigo.Album
+	artists    map[string]spotigo.Artist
+	playlists  map[string]*playlist
+	shows      map[string]spotigo.Show
+	episodes   map[string]spotigo.Episode
+	audiobooks map[string]spotigo.Audiobook
+	features   map[string]spotigo.AudioFeatures
+	analyses   map[string]spotigo.AudioAnalysis
+}
+
+// Playlist metadata with its tracks stored as ordered IDs
+type playlist struct {
+	meta     spotigo.Playlist
+	trackIDs []string
+}
+
+func newCatalog() catalog {
+	return catalog{
+		tracks:     make(map[string]spotigo.Track),
+		albums:     make(map[string]spotigo.Album),
+		artists:    make(map[string]spotigo.Artist),
+		playlists:  make(map[string]*playlist),
+		shows:      make(map[string]spotigo.Show),
+		episodes:   make(map[string]spotigo.Episode),
+		audiobooks: make(map[string]spotigo.Audiobook),
+		features:   make(map[string]spotigo.AudioFeatures),
+		analyses:   make(map[string]spotigo.AudioAnalysis),
+	}
+}
+
+// Add Tracks to the catalog, replacing any with the same ID
+// Type, URI and Href are filled in when empty
+func (s *Server) AddTrack(tracks ...spotigo.Track) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range tracks {
+		t.Type, t.URI, t.Href = s.identity("track", t.ID, t.Type, t.URI, t.Href)
+		s.catalog.tracks[t.ID] = t
+	}
+}
+
+// Add Albums to the catalog, replacing any with the same ID
+func (s *Server) AddAlbum(albums ...spotigo.Album) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range albums {
+		a.Type, a.URI, a.Href = s.identity("album", a.ID, a.Type, a.URI, a.Href)
+		s.catalog.albums[a.ID] = a
+	}
+}
+
+// Add Artists to the catalog, replacing any with the same ID
+func (s *Server) AddArtist(artists ...spotigo.Artist) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range artists {
+		a.Type, a.URI, a.Href = s.identity("artist", a.ID, a.Type, a.URI, a.Href)
+		s.catalog.artists[a.ID] = a
+	}
+}
+
+// Add a Playlist to the catalog containing the given catalog Tracks in order
+// Any tracks already set on p are ignored
+func (s *Server) AddPlaylist(p spotigo.Playlist, trackIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.Type, p.URI, p.Href = s.identity("playlist", p.ID, p.Type, p.URI, p.Href)
+	p.Tracks.Items = nil
+	s.catalog.playlists[p.ID] = &playlist{meta: p, trackIDs: append([]string(nil), trackIDs...)}
+}
+
+// Add Shows to the catalog, replacing any with the same ID
+func (s *Server) AddShow(shows ...spotigo.Show) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sh := range shows {
+		sh.Type, sh.URI, sh.Href = s.identity("show", sh.ID, sh.Type, sh.URI, sh.Href)
+		s.catalog.shows[sh.ID] = sh
+	}
+}
+
+// Add Episodes to the catalog, replacing any with the same ID
+func (s *Server) AddEpisode(episodes ...spotigo.Episode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range episodes {
+		e.Type, e.URI, e.Href = s.identity("episode", e.ID, e.Type, e.URI, e.Href)
+		s.catalog.episodes[e.ID] = e
+	}
+}
+
+// Add Audiobooks to the catalog, replacing any with the same ID
+func (s *Server) AddAudiobook(audiobooks ...spotigo.Audiobook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range audiobooks {
+		a.Type, a.URI, a.Href = s.identity("audiobook", a.ID, a.Type, a.URI, a.Href)
+		s.catalog.audiobooks[a.ID] = a
+	}
+}
+
+// Set the audio features returned for a Track
+func (s *Server) SetAudioFeatures(trackID string, f spotigo.AudioFeatures) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.catalog.features[trackID] = f
+}
+
+// Set the audio analysis returned for a Track
+func (s *Server) SetAudioAnalysis(trackID string, a spotigo.AudioAnalysis) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.catalog.analyses[trackID] = a
+}
+
+// Default the type, URI and Href of a catalog object
+func (s *Server) identity(typ, id, curType, uri, href string) (string, string, string) {
+	if curType == "" {
+		curType = typ
+	}
+	if uri == "" {
+		uri = "spotify:" + typ + ":" + id
+	}
+	if href == "" {
+		href = s.APIURL() + typ + "s/" + id
+	}
+	return curType, uri, href
+}
+
+// Serve non-"me" Web API endpoints
+func (s *Server) serveCatalog(w http.ResponseWriter, r *http.Request, userID string, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		v     interface{}
+		found bool
+	)
+	switch {
+	case len(parts) == 1 && parts[0] == "search" && r.Method == http.MethodGet:
+		s.handleSearch(w, r)
+		return
+	case len(parts) == 1 && (parts[0] == "tracks" || parts[0] == "albums" || parts[0] == "artists"):
+		s.handleSeveral(w, r, parts[0])
+		return
+	case len(parts) == 2 && parts[0] == "tracks":
+		v, found = s.catalog.tracks[parts[1]]
+	case len(parts) == 2 && parts[0] == "albums":
+		v, found = s.catalog.albums[parts[1]]
+	case len(parts) == 2 && parts[0] == "artists":
+		v, found = s.catalog.artists[parts[1]]
+	case len(parts) == 2 && parts[0] == "shows":
+		v, found = s.catalog.shows[parts[1]]
+	case len(parts) == 2 && parts[0] == "episodes":
+		v, found = s.catalog.episodes[parts[1]]
+	case len(parts) == 2 && parts[0] == "audiobooks":
+		v, found = s.catalog.audiobooks[parts[1]]
+	case len(parts) == 2 && parts[0] == "audio-features":
+		v, found = s.catalog.features[parts[1]]
+	case len(parts) == 2 && parts[0] == "audio-analysis":
+		v, found = s.catalog.analyses[parts[1]]
+	case len(parts) == 2 && parts[0] == "playlists":
+		var p *playlist
+		if p, found = s.catalog.playlists[parts[1]]; found {
+			v = s.renderPlaylist(p)
+		}
+	case len(parts) == 3 && parts[0] == "playlists" && parts[2] == "tracks":
+		var p *playlist
+		if p, found = s.catalog.playlists[parts[1]]; found {
+			limit, offset := pageParams(r, playlistTracksLimit, playlistTracksLimit)
+			v = s.playlistTracks(p, limit, offset)
+		}
+	case len(parts) == 3 && parts[0] == "playlists" && parts[2] == "followers":
+		s.handleFollowPlaylist(w, r, userID, parts[1])
+		return
+	default:
+		writeError(w, http.StatusNotFound, "Service not found", "")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "Non existing id", "")
+		return
+	}
+	if parts[0] == "playlists" {
+		writeTagged(w, r, v)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// Render a playlist object with its first page of tracks embedded
+func (s *Server) renderPlaylist(p *playlist) map[string]interface{} {
+	var m map[string]interface{}
+	b, _ := json.Marshal(p.meta)
+	json.Unmarshal(b, &m)
+	m["tracks"] = s.playlistTracks(p, playlistTracksLimit, 0)
+	return m
+}
+
+// Render one page of a playlist's tracks
+func (s *Server) playlistTracks(p *playlist, limit, offset int) map[string]interface{} {
+	items := make([]interface{}, 0)
+	for _, id := range window(p.trackIDs, limit, offset) {
+		items = append(items, map[string]interface{}{
+			"added_at": "2022-01-01T00:00:00Z",
+			"is_local": false,
+			"track":    s.catalog.tracks[id],
+		})
+	}
+	href := s.APIURL() + "playlists/" + p.meta.ID + "/tracks"
+	return page(href, items, limit, offset, len(p.trackIDs))
+}
+
+// Read limit and offset query parameters, clamping limit to max
+func pageParams(r *http.Request, def int, max int) (int, int) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = def
+	}
+	if limit > max {
+		limit = max
+	}
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// Slice of ids on the page described by limit and offset
+func window(ids []string, limit, offset int) []string {
+	if offset >= len(ids) {
+		return nil
+	}
+	end := offset + limit
+	if end > len(ids) {
+		end = len(ids)
+	}
+	return ids[offset:end]
+}
+
+// Render a paging object; href may already contain a query string
+func page(href string, items []interface{}, limit, offset, total int) map[string]interface{} {
+	sep := "?"
+	if strings.Contains(href, "?") {
+		sep = "&"
+	}
+	link := func(off int) string {
+		return href + sep + "offset=" + strconv.Itoa(off) + "&limit=" + strconv.Itoa(limit)
+	}
+
+	p := map[string]interface{}{
+		"href":     link(offset),
+		"items":    items,
+		"limit":    limit,
+		"offset":   offset,
+		"total":    total,
+		"next":     nil,
+		"previous": nil,
+	}
+	if offset+limit < total {
+		p["next"] = link(offset + limit)
+	}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		p["previous"] = link(prev)
+	}
+	return p
+}
+
+// Most IDs accepted by the several-items endpoints
+var severalLimits = map[string]int{"tracks": 50, "albums": 20, "artists": 50}
+
+// Serve GET /{tracks,albums,artists}?ids=, with null for unknown IDs
+func (s *Server) handleSeveral(w http.ResponseWriter, r *http.Request, endpoint string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+	if r.URL.Query().Get("ids") == "" {
+		writeError(w, http.StatusBadRequest, "invalid request: no ids", "")
+		return
+	}
+	if len(ids) > severalLimits[endpoint] {
+		writeError(w, http.StatusBadRequest, "Invalid request: too many ids requested", "")
+		return
+	}
+
+	items := make([]interface{}, len(ids))
+	for i, id := range ids {
+		var (
+			v     interface{}
+			found bool
+		)
+		switch endpoint {
+		case "tracks":
+			v, found = s.catalog.tracks[id]
+		case "albums":
+			v, found = s.catalog.albums[id]
+		case "artists":
+			v, found = s.catalog.artists[id]
+		}
+		if found {
+			items[i] = v
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{endpoint: items})
+}
@@ -0,0 +1,286 @@
+package spotigotest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/adamgamba/spotigo"
+)
+
+// userState struct- a user's profile, library and player
+type userState struct {
+	profile spotigo.Profile
+	library Library
+	player  PlayerState
+}
+
+// Library is a snapshot of a user's saved and followed items, as IDs in the
+// order they were added
+type Library struct {
+	Tracks    []string
+	Albums    []string
+	Playlists []string
+	Artists   []string
+	Users     []string
+}
+
+// Add a user, replacing any existing user with the same profile ID
+// New users have an empty library and no devices
+func (s *Server) AddUser(p spotigo.Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.Type == "" {
+		p.Type = "user"
+	}
+	if p.URI == "" {
+		p.URI = "spotify:user:" + p.ID
+	}
+	s.users[p.ID] = &userState{profile: p, player: PlayerState{RepeatState: "off", Volume: 100}}
+}
+
+// Make logins through the fake authorize endpoint sign in as userID
+func (s *Server) SetLoginUser(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.login = userID
+}
+
+// Get a snapshot of a user's library
+func (s *Server) Library(userID string) Library {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lib := Library{}
+	if u, ok := s.users[userID]; ok {
+		lib.Tracks = append(lib.Tracks, u.library.Tracks...)
+		lib.Albums = append(lib.Albums, u.library.Albums...)
+		lib.Playlists = append(lib.Playlists, u.library.Playlists...)
+		lib.Artists = append(lib.Artists, u.library.Artists...)
+		lib.Users = append(lib.Users, u.library.Users...)
+	}
+	return lib
+}
+
+// Replace a user's library
+func (s *Server) SetLibrary(userID string, lib Library) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[userID]; ok {
+		u.library = lib
+	}
+}
+
+// Serve the "me" endpoints for the user owning the access token
+func (s *Server) serveMe(w http.ResponseWriter, r *http.Request, userID string, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Non existing user", "")
+		return
+	}
+
+	if len(parts) == 0 {
+		writeJSON(w, http.StatusOK, u.profile)
+		return
+	}
+
+	switch parts[0] {
+	case "tracks":
+		s.handleSaved(w, r, parts[1:], &u.library.Tracks, "track", func(id string) (interface{}, bool) {
+			t, ok := s.catalog.tracks[id]
+			return t, ok
+		})
+	case "albums":
+		s.handleSaved(w, r, parts[1:], &u.library.Albums, "album", func(id string) (interface{}, bool) {
+			a, ok := s.catalog.albums[id]
+			return a, ok
+		})
+	case "playlists":
+		if len(parts) != 1 || r.Method != http.MethodGet {
+			writeError(w, http.StatusNotFound, "Service not found", "")
+			return
+		}
+		limit, offset := pageParams(r, 20, 50)
+		items := make([]interface{}, 0)
+		for _, id := range window(u.library.Playlists, limit, offset) {
+			if p, ok := s.catalog.playlists[id]; ok {
+				items = append(items, s.renderPlaylist(p))
+			}
+		}
+		writeJSON(w, http.StatusOK, page(s.APIURL()+"me/playlists", items, limit, offset, len(u.library.Playlists)))
+	case "following":
+		s.handleFollowing(w, r, parts[1:], u)
+	case "player":
+		s.handlePlayer(w, r, parts[1:], u)
+	default:
+		writeError(w, http.StatusNotFound, "Service not found", "")
+	}
+}
+
+// Serve me/tracks and me/albums: paged GET, PUT/DELETE of ids and contains
+// lookup resolves a saved ID to the catalog object rendered as the item
+func (s *Server) handleSaved(w http.ResponseWriter, r *http.Request, parts []string, saved *[]string, typ string, lookup func(string) (interface{}, bool)) {
+	switch {
+	case len(parts) == 1 && parts[0] == "contains" && r.Method == http.MethodGet:
+		ids := splitIDs(r)
+		res := make([]bool, len(ids))
+		for i, id := range ids {
+			res[i] = contains(*saved, id)
+		}
+		writeJSON(w, http.StatusOK, res)
+	case len(parts) != 0:
+		writeError(w, http.StatusNotFound, "Service not found", "")
+	case r.Method == http.MethodGet:
+		limit, offset := pageParams(r, 20, 50)
+		items := make([]interface{}, 0)
+		for _, id := range window(*saved, limit, offset) {
+			v, _ := lookup(id)
+			items = append(items, map[string]interface{}{
+				"added_at": "2022-01-01T00:00:00Z",
+				typ:        v,
+			})
+		}
+		writeJSON(w, http.StatusOK, page(s.APIURL()+"me/"+typ+"s", items, limit, offset, len(*saved)))
+	case r.Method == http.MethodPut:
+		ids := splitIDs(r)
+		for _, id := range ids {
+			if _, ok := lookup(id); !ok {
+				writeError(w, http.StatusBadRequest, "Invalid id", "")
+				return
+			}
+		}
+		*saved = addIDs(*saved, ids)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete:
+		*saved = removeIDs(*saved, splitIDs(r))
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// Serve me/following: cursor-paged GET of artists, PUT/DELETE and contains
+func (s *Server) handleFollowing(w http.ResponseWriter, r *http.Request, parts []string, u *userState) {
+	typ := r.URL.Query().Get("type")
+	var followed *[]string
+	switch typ {
+	case "artist":
+		followed = &u.library.Artists
+	case "user":
+		followed = &u.library.Users
+	default:
+		writeError(w, http.StatusBadRequest, "Invalid type", "")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "contains" && r.Method == http.MethodGet:
+		ids := splitIDs(r)
+		res := make([]bool, len(ids))
+		for i, id := range ids {
+			res[i] = contains(*followed, id)
+		}
+		writeJSON(w, http.StatusOK, res)
+	case len(parts) != 0:
+		writeError(w, http.StatusNotFound, "Service not found", "")
+	case r.Method == http.MethodGet && typ == "artist":
+		limit, _ := pageParams(r, 20, 50)
+		start := 0
+		if after := r.URL.Query().Get("after"); after != "" {
+			for i, id := range *followed {
+				if id == after {
+					start = i + 1
+				}
+			}
+		}
+		items := make([]interface{}, 0)
+		ids := window(*followed, limit, start)
+		for _, id := range ids {
+			items = append(items, s.catalog.artists[id])
+		}
+		cursors := map[string]interface{}{"after": nil}
+		if start+limit < len(*followed) {
+			cursors["after"] = ids[len(ids)-1]
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"artists": map[string]interface{}{
+			"href":    s.APIURL() + "me/following?type=artist",
+			"items":   items,
+			"limit":   limit,
+			"total":   len(*followed),
+			"cursors": cursors,
+		}})
+	case r.Method == http.MethodPut:
+		*followed = addIDs(*followed, splitIDs(r))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		*followed = removeIDs(*followed, splitIDs(r))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// Serve playlists/{id}/followers: follow (save) or unfollow a playlist
+func (s *Server) handleFollowPlaylist(w http.ResponseWriter, r *http.Request, userID string, playlistID string) {
+	u, ok := s.users[userID]
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "This request requires user authentication.", "")
+		return
+	}
+	if _, ok := s.catalog.playlists[playlistID]; !ok {
+		writeError(w, http.StatusNotFound, "Non existing id", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		u.library.Playlists = addIDs(u.library.Playlists, []string{playlistID})
+	case http.MethodDelete:
+		u.library.Playlists = removeIDs(u.library.Playlists, []string{playlistID})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// IDs from the comma-separated ids query parameter
+func splitIDs(r *http.Request) []string {
+	raw := r.URL.Query().Get("ids")
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
+
+// Whether ids contains id
+func contains(ids []string, id string) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Append the IDs not already in ids
+func addIDs(ids []string, add []string) []string {
+	for _, id := range add {
+		if !contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Remove every occurrence of the given IDs
+func removeIDs(ids []string, remove []string) []string {
+	kept := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !contains(remove, id) {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
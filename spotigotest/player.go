@@ -0,0 +1,232 @@
+package spotigotest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adamgamba/spotigo"
+)
+
+// PlayerState is a snapshot of a user's playback state
+type PlayerState struct {
+	// Devices available to the user; Device.Active marks the active one
+	Devices []spotigo.Device
+	// ID of the current Track; empty when nothing is loaded
+	TrackID      string
+	ContextURI   string
+	IsPlaying    bool
+	ProgressMs   int
+	ShuffleState bool
+	// "off", "track" or "context"
+	RepeatState string
+	Volume      int
+	// Track IDs waiting in the user's queue
+	Queue []string
+}
+
+// Add a playback device for a user
+func (s *Server) AddDevice(userID string, d spotigo.Device) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[userID]; ok {
+		u.player.Devices = append(u.player.Devices, d)
+	}
+}
+
+// Get a snapshot of a user's playback state
+func (s *Server) Player(userID string) PlayerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		return PlayerState{}
+	}
+	p := u.player
+	p.Devices = append([]spotigo.Device(nil), p.Devices...)
+	p.Queue = append([]string(nil), p.Queue...)
+	return p
+}
+
+// Replace a user's playback state
+func (s *Server) SetPlayer(userID string, p PlayerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u, ok := s.users[userID]; ok {
+		u.player = p
+	}
+}
+
+// Index of the active device, or -1
+func (p *PlayerState) active() int {
+	for i, d := range p.Devices {
+		if d.Active {
+			return i
+		}
+	}
+	return -1
+}
+
+// Render the playback state object returned by me/player
+func (s *Server) renderPlayback(p *PlayerState) map[string]interface{} {
+	var item interface{}
+	if t, ok := s.catalog.tracks[p.TrackID]; ok {
+		item = t
+	}
+	var device interface{}
+	if i := p.active(); i >= 0 {
+		device = p.Devices[i]
+	}
+	var context interface{}
+	if p.ContextURI != "" {
+		context = map[string]interface{}{"uri": p.ContextURI, "type": strings.Split(p.ContextURI, ":")[1]}
+	}
+	return map[string]interface{}{
+		"device":                 device,
+		"shuffle_state":          p.ShuffleState,
+		"repeat_state":           p.RepeatState,
+		"timestamp":              0,
+		"context":                context,
+		"progress_ms":            p.ProgressMs,
+		"item":                   item,
+		"currently_playing_type": "track",
+		"is_playing":             p.IsPlaying,
+	}
+}
+
+// Serve me/player and its sub-resources
+func (s *Server) handlePlayer(w http.ResponseWriter, r *http.Request, parts []string, u *userState) {
+	p := &u.player
+	route := r.Method + " " + strings.Join(parts, "/")
+
+	// Read-only endpoints are available to every user
+	switch route {
+	case "GET ", "GET currently-playing":
+		if p.active() < 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.renderPlayback(p))
+		return
+	case "GET devices":
+		devices := append([]spotigo.Device{}, p.Devices...)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"devices": devices})
+		return
+	}
+
+	if u.profile.Product != "premium" {
+		writeError(w, http.StatusForbidden, "Player command failed: Premium required", "PREMIUM_REQUIRED")
+		return
+	}
+
+	if route == "PUT " {
+		var body struct {
+			DeviceIDs []string `json:"device_ids"`
+			Play      bool     `json:"play"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.DeviceIDs) != 1 {
+			writeError(w, http.StatusBadRequest, "Malformed json", "")
+			return
+		}
+		found := false
+		for i := range p.Devices {
+			p.Devices[i].Active = p.Devices[i].ID == body.DeviceIDs[0]
+			found = found || p.Devices[i].Active
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "Device not found", "")
+			return
+		}
+		if body.Play {
+			p.IsPlaying = true
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	dev := p.active()
+	if dev < 0 {
+		writeError(w, http.StatusNotFound, "Player command failed: No active device found", "NO_ACTIVE_DEVICE")
+		return
+	}
+
+	query := r.URL.Query()
+	switch route {
+	case "PUT play":
+		var body struct {
+			URIs       []string `json:"uris"`
+			ContextURI string   `json:"context_uri"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeError(w, http.StatusBadRequest, "Malformed json", "")
+				return
+			}
+		}
+		if len(body.URIs) > 0 {
+			id := strings.TrimPrefix(body.URIs[0], "spotify:track:")
+			if _, ok := s.catalog.tracks[id]; !ok {
+				writeError(w, http.StatusBadRequest, "Invalid track uri: "+body.URIs[0], "")
+				return
+			}
+			p.TrackID, p.ContextURI, p.ProgressMs = id, "", 0
+		} else if body.ContextURI != "" {
+			p.ContextURI, p.ProgressMs = body.ContextURI, 0
+		}
+		p.IsPlaying = true
+	case "PUT pause":
+		if !p.IsPlaying {
+			writeError(w, http.StatusForbidden, "Player command failed: Restriction violated", "ALREADY_PAUSED")
+			return
+		}
+		p.IsPlaying = false
+	case "POST next":
+		if len(p.Queue) > 0 {
+			p.TrackID, p.Queue = p.Queue[0], p.Queue[1:]
+		}
+		p.ProgressMs = 0
+	case "POST previous":
+		p.ProgressMs = 0
+	case "PUT volume":
+		vol, err := strconv.Atoi(query.Get("volume_percent"))
+		if err != nil || vol < 0 || vol > 100 {
+			writeError(w, http.StatusBadRequest, "Invalid volume_percent", "")
+			return
+		}
+		p.Volume = vol
+		p.Devices[dev].Volume = vol
+	case "PUT repeat":
+		state := query.Get("state")
+		if state != "off" && state != "track" && state != "context" {
+			writeError(w, http.StatusBadRequest, "Invalid state", "")
+			return
+		}
+		p.RepeatState = state
+	case "PUT shuffle":
+		state, err := strconv.ParseBool(query.Get("state"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid state", "")
+			return
+		}
+		p.ShuffleState = state
+	case "PUT seek":
+		ms, err := strconv.ParseFloat(query.Get("position_ms"), 64)
+		if err != nil || ms < 0 {
+			writeError(w, http.StatusBadRequest, "Invalid position_ms", "")
+			return
+		}
+		p.ProgressMs = int(ms)
+	case "POST queue":
+		id := strings.TrimPrefix(query.Get("uri"), "spotify:track:")
+		if _, ok := s.catalog.tracks[id]; !ok {
+			writeError(w, http.StatusBadRequest, "Invalid track uri: "+query.Get("uri"), "")
+			return
+		}
+		p.Queue = append(p.Queue, id)
+	default:
+		writeError(w, http.StatusNotFound, "Service not found", "")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
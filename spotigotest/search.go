@@ -0,0 +1,266 @@
+package spotigotest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adamgamba/spotigo"
+)
+
+// Searchable fields of a catalog item, lower-cased
+// The "" field holds the names plain search terms are matched against
+type searchDoc map[string][]string
+
+// A parsed search query: plain terms plus field filters
+type searchQuery struct {
+	terms   []string
+	filters map[string][]string
+}
+
+// Search the catalog
+// Every plain word of q must appear (case-insensitively) in an item's name, or
+// in the names of its artists, album, show, publisher, authors or narrators.
+// The artist:, album:, track:, genre:, isrc:, upc: and year: filters must
+// match the corresponding field (upc: ignoring leading zeros), and tag:hipster matches popularity under 10;
+// items without the field never match. tag:new matches every album.
+// The market and include_external parameters are accepted but ignored
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	query := parseSearch(values.Get("q"))
+	if len(query.terms) == 0 && len(query.filters) == 0 {
+		writeError(w, http.StatusBadRequest, "No search query", "")
+		return
+	}
+	types := strings.Split(values.Get("type"), ",")
+	limit, offset := pageParams(r, 20, 50)
+
+	res := make(map[string]interface{})
+	for _, typ := range types {
+		docs := make(map[string]searchDoc)
+		switch typ {
+		case "track":
+			for id, t := range s.catalog.tracks {
+				docs[id] = s.trackDoc(t)
+			}
+		case "album":
+			for id, a := range s.catalog.albums {
+				docs[id] = albumDoc(a)
+			}
+		case "artist":
+			for id, a := range s.catalog.artists {
+				docs[id] = searchDoc{
+					"":        {a.Name},
+					"artist":  {a.Name},
+					"genre":   a.Genres,
+					"hipster": {strconv.Itoa(a.Popularity)},
+				}
+			}
+		case "playlist":
+			for id, p := range s.catalog.playlists {
+				docs[id] = searchDoc{"": {p.meta.Name, p.meta.Owner.DisplayName}}
+			}
+		case "show":
+			for id, sh := range s.catalog.shows {
+				docs[id] = searchDoc{"": {sh.Name, sh.Publisher}}
+			}
+		case "episode":
+			for id, e := range s.catalog.episodes {
+				docs[id] = searchDoc{"": {e.Name, e.Show.Name}, "year": {year(e.ReleaseDate)}}
+			}
+		case "audiobook":
+			for id, a := range s.catalog.audiobooks {
+				names := []string{a.Name, a.Publisher}
+				for _, p := range a.Authors {
+					names = append(names, p.Name)
+				}
+				for _, p := range a.Narrators {
+					names = append(names, p.Name)
+				}
+				docs[id] = searchDoc{"": names}
+			}
+		default:
+			writeError(w, http.StatusBadRequest, "Bad search type field "+typ, "")
+			return
+		}
+
+		ids := make([]string, 0)
+		for id, doc := range docs {
+			if query.matches(doc) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+
+		items := make([]interface{}, 0)
+		for _, id := range window(ids, limit, offset) {
+			switch typ {
+			case "track":
+				items = append(items, s.catalog.tracks[id])
+			case "album":
+				items = append(items, s.catalog.albums[id])
+			case "artist":
+				items = append(items, s.catalog.artists[id])
+			case "playlist":
+				items = append(items, s.renderPlaylist(s.catalog.playlists[id]))
+			case "show":
+				items = append(items, s.catalog.shows[id])
+			case "episode":
+				items = append(items, s.catalog.episodes[id])
+			case "audiobook":
+				items = append(items, s.catalog.audiobooks[id])
+			}
+		}
+		href := s.APIURL() + "search?q=" + url.QueryEscape(values.Get("q")) + "&type=" + typ
+		res[typ+"s"] = page(href, items, limit, offset, len(ids))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Searchable fields of a Track; its genres are those of its catalog artists
+func (s *Server) trackDoc(t spotigo.Track) searchDoc {
+	doc := searchDoc{
+		"":        {t.Name, t.Album.Name},
+		"track":   {t.Name},
+		"album":   {t.Album.Name},
+		"isrc":    {t.ExternalIds.Isrc},
+		"year":    {year(t.Album.ReleaseDate)},
+		"hipster": {strconv.Itoa(t.Popularity)},
+	}
+	for _, a := range t.Artists {
+		doc[""] = append(doc[""], a.Name)
+		doc["artist"] = append(doc["artist"], a.Name)
+		doc["genre"] = append(doc["genre"], s.catalog.artists[a.ID].Genres...)
+	}
+	return doc
+}
+
+// Searchable fields of an Album
+func albumDoc(a spotigo.Album) searchDoc {
+	doc := searchDoc{
+		"":        {a.Name},
+		"album":   {a.Name},
+		"upc":     {a.ExternalIds.Upc},
+		"year":    {year(a.ReleaseDate)},
+		"hipster": {strconv.Itoa(a.Popularity)},
+		"new":     {"new"},
+	}
+	for _, artist := range a.Artists {
+		doc[""] = append(doc[""], artist.Name)
+		doc["artist"] = append(doc["artist"], artist.Name)
+	}
+	return doc
+}
+
+// Year of a release date such as "2021-03-05"
+func year(date string) string {
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
+
+// Split q into plain terms and field:value filters; quoted values may
+// contain spaces
+func parseSearch(q string) searchQuery {
+	query := searchQuery{filters: make(map[string][]string)}
+	for _, tok := range splitQuoted(strings.ToLower(q)) {
+		field, value := "", tok
+		if i := strings.Index(tok, ":"); i > 0 {
+			field, value = tok[:i], strings.Trim(tok[i+1:], `"`)
+		}
+		switch field {
+		case "":
+			query.terms = append(query.terms, strings.Trim(value, `"`))
+		case "tag":
+			query.filters[value] = append(query.filters[value], "")
+		default:
+			query.filters[field] = append(query.filters[field], value)
+		}
+	}
+	return query
+}
+
+// Split s on spaces outside double quotes
+func splitQuoted(s string) []string {
+	var (
+		toks   []string
+		cur    strings.Builder
+		quoted bool
+	)
+	for _, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			cur.WriteRune(c)
+		case c == ' ' && !quoted:
+			if cur.Len() > 0 {
+				toks = append(toks, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(c)
+		}
+	}
+	if cur.Len() > 0 {
+		toks = append(toks, cur.String())
+	}
+	return toks
+}
+
+// Whether an item matches every term and filter of the query
+func (q searchQuery) matches(doc searchDoc) bool {
+	haystack := strings.ToLower(strings.Join(doc[""], " "))
+	for _, term := range q.terms {
+		if !strings.Contains(haystack, term) {
+			return false
+		}
+	}
+	for field, values := range q.filters {
+		for _, v := range values {
+			if !matchField(field, v, doc[field]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Whether a filter value matches one of a field's values
+func matchField(field, value string, have []string) bool {
+	for _, h := range have {
+		h = strings.ToLower(h)
+		switch field {
+		case "year":
+			from, to := value, value
+			if i := strings.Index(value, "-"); i >= 0 {
+				from, to = value[:i], value[i+1:]
+			}
+			if h != "" && h >= from && h <= to {
+				return true
+			}
+		case "hipster":
+			if pop, err := strconv.Atoi(h); err == nil && pop < 10 {
+				return true
+			}
+		case "new":
+			return true
+		case "isrc":
+			if h == value {
+				return true
+			}
+		case "upc":
+			// A UPC matches its 13-digit EAN form, with a leading 0
+			if strings.TrimLeft(h, "0") == strings.TrimLeft(value, "0") {
+				return true
+			}
+		default:
+			if strings.Contains(h, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
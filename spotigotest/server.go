@@ -0,0 +1,410 @@
+// Package spotigotest provides an in-process fake of the Spotify Web API and
+// Accounts Service for testing code built on spotigo.
+//
+// A Server is backed by a seedable in-memory catalog (tracks, albums, artists,
+// playlists, audio features and analyses) and per-user library and player
+// state. Point a Query or User at it with the Options method:
+//
+//	srv := spotigotest.NewServer()
+//	defer srv.Close()
+//	srv.AddTrack(spotigo.Track{ID: "t1", Name: "Disco Man"})
+//	q, err := spotigo.NewQuery("id", "secret", srv.Options()...)
+package spotigotest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+)
+
+// DefaultUserID is the ID of the premium user every new Server starts with.
+// Logins through the fake authorize endpoint sign in as this user unless
+// SetLoginUser picks another.
+const DefaultUserID = "testuser"
+
+// Lifetime of every access token issued by the fake token endpoint
+const tokenLifetime = time.Hour
+
+// Server is a fake Spotify Web API and Accounts Service running on an
+// httptest.Server. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// ClientID and ClientSecret, when ClientID is non-empty, are the only
+	// credentials the token endpoint accepts. Set them before use.
+	ClientID     string
+	ClientSecret string
+
+	mu       sync.Mutex
+	catalog  catalog
+	users    map[string]*userState
+	login    string
+	tokens   map[string]tokenInfo
+	refresh  map[string]tokenInfo
+	codes    map[string]authCode
+	requests []string
+	failures []failure
+}
+
+// A canned failure for an upcoming Web API request
+type failure struct {
+	status     int
+	retryAfter time.Duration
+}
+
+// Owner of an issued token; userID is empty for client-credentials tokens
+type tokenInfo struct {
+	userID string
+	scope  string
+	expiry time.Time
+	// Issued to a public (PKCE) client, which refreshes without a secret
+	public bool
+}
+
+// Authorization code issued by the authorize endpoint
+type authCode struct {
+	userID      string
+	scope       string
+	redirectURI string
+	// PKCE code_challenge; empty when the login did not use PKCE
+	challenge string
+}
+
+// Create and start a new Server with an empty catalog and the default user
+func NewServer() *Server {
+	s := &Server{
+		catalog: newCatalog(),
+		users:   make(map[string]*userState),
+		login:   DefaultUserID,
+		tokens:  make(map[string]tokenInfo),
+		refresh: make(map[string]tokenInfo),
+		codes:   make(map[string]authCode),
+	}
+	s.AddUser(spotigo.Profile{ID: DefaultUserID, DisplayName: "Test User", Product: "premium"})
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Base URL of the fake Web API, for spotigo.WithAPIURL
+func (s *Server) APIURL() string {
+	return s.URL + "/v1/"
+}
+
+// Base URL of the fake Accounts Service, for spotigo.WithAccountsURL
+func (s *Server) AccountsURL() string {
+	return s.URL + "/"
+}
+
+// Options that point a Query or User at this Server
+func (s *Server) Options() []spotigo.Option {
+	return []spotigo.Option{
+		spotigo.WithAPIURL(s.APIURL()),
+		spotigo.WithAccountsURL(s.AccountsURL()),
+		spotigo.WithHTTPClient(s.Client()),
+	}
+}
+
+// Requests returns every request served so far as "METHOD /path?query"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Respond to the next n Web API requests with status instead of serving them
+// A positive retryAfter is sent in a Retry-After header, rounded up to whole
+// seconds; use it with 429 Too Many Requests to exercise rate limiting
+func (s *Server) FailNext(n int, status int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{status: status, retryAfter: retryAfter})
+	}
+}
+
+// Issue an access token for a user without going through the OAuth2 flow
+// The token is granted the given scopes
+func (s *Server) UserToken(userID string, scopes ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok := randomToken()
+	s.tokens[tok] = tokenInfo{userID: userID, scope: strings.Join(scopes, " "), expiry: time.Now().Add(tokenLifetime)}
+	return tok
+}
+
+// Expire every access token issued so far, as if an hour had passed
+// Refresh tokens stay valid
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tok, info := range s.tokens {
+		info.expiry = time.Now()
+		s.tokens[tok] = info
+	}
+}
+
+// Route a request to its handler
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+	s.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/api/token":
+		s.handleToken(w, r)
+	case r.URL.Path == "/authorize":
+		s.handleAuthorize(w, r)
+	case strings.HasPrefix(r.URL.Path, "/v1/"):
+		s.serveAPI(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "Service not found", "")
+	}
+}
+
+// Route a Web API request to its handler after checking the access token
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var fail *failure
+	if len(s.failures) > 0 {
+		fail = &s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mu.Unlock()
+	if fail != nil {
+		if fail.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((fail.retryAfter+time.Second-1)/time.Second)))
+		}
+		writeError(w, fail.status, http.StatusText(fail.status), "")
+		return
+	}
+
+	info, ok := s.authorize(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Invalid access token", "")
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	parts := strings.Split(path, "/")
+
+	if parts[0] == "me" {
+		if info.userID == "" {
+			writeError(w, http.StatusUnauthorized, "This request requires user authentication.", "")
+			return
+		}
+		s.serveMe(w, r, info.userID, parts[1:])
+		return
+	}
+	s.serveCatalog(w, r, info.userID, parts)
+}
+
+// Look up the token in the Authorization header
+func (s *Server) authorize(r *http.Request) (tokenInfo, bool) {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		return tokenInfo{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.tokens[strings.TrimPrefix(h, "Bearer ")]
+	if !ok || time.Now().After(info.expiry) {
+		return tokenInfo{}, false
+	}
+	return info, true
+}
+
+// Fake authorize endpoint- immediately approves the login as the login user
+// and redirects back to redirect_uri with a code and the original state
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	redirect, err := url.Parse(values.Get("redirect_uri"))
+	if err != nil || redirect.String() == "" {
+		http.Error(w, "Illegal redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if values.Get("response_type") != "code" {
+		http.Error(w, "Unsupported response_type", http.StatusBadRequest)
+		return
+	}
+	challenge := values.Get("code_challenge")
+	if challenge != "" && values.Get("code_challenge_method") != "S256" {
+		http.Error(w, "Unsupported code_challenge_method", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	code := randomToken()
+	s.codes[code] = authCode{
+		userID:      s.login,
+		scope:       values.Get("scope"),
+		redirectURI: values.Get("redirect_uri"),
+		challenge:   challenge,
+	}
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	if state := values.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// Fake token endpoint- supports the client_credentials, authorization_code
+// (with or without PKCE) and refresh_token grants
+// Public clients, which send a client_id but no secret, may only redeem PKCE
+// codes and the refresh tokens issued for them
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAuthError(w, http.StatusMethodNotAllowed, "invalid_request")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeAuthError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	ok, public := s.checkClient(r)
+	if !ok {
+		writeAuthError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var info tokenInfo
+	refresh := ""
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+		if public {
+			writeAuthError(w, http.StatusUnauthorized, "invalid_client")
+			return
+		}
+	case "authorization_code":
+		code, ok := s.codes[r.PostForm.Get("code")]
+		if !ok || code.redirectURI != r.PostForm.Get("redirect_uri") {
+			writeAuthError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		if public && code.challenge == "" {
+			writeAuthError(w, http.StatusUnauthorized, "invalid_client")
+			return
+		}
+		if code.challenge != "" && !verifyChallenge(code.challenge, r.PostForm.Get("code_verifier")) {
+			writeAuthError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		delete(s.codes, r.PostForm.Get("code"))
+		info = tokenInfo{userID: code.userID, scope: code.scope, public: public}
+		refresh = randomToken()
+		s.refresh[refresh] = info
+	case "refresh_token":
+		var ok bool
+		refresh = r.PostForm.Get("refresh_token")
+		info, ok = s.refresh[refresh]
+		if !ok {
+			writeAuthError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		if public && !info.public {
+			writeAuthError(w, http.StatusUnauthorized, "invalid_client")
+			return
+		}
+	default:
+		writeAuthError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	access := randomToken()
+	info.expiry = time.Now().Add(tokenLifetime)
+	s.tokens[access] = info
+
+	res := map[string]interface{}{
+		"access_token": access,
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenLifetime / time.Second),
+	}
+	if refresh != "" {
+		res["refresh_token"] = refresh
+		res["scope"] = info.scope
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Check client credentials sent by HTTP Basic auth or in the form body
+// public reports a client that identified itself without a secret
+func (s *Server) checkClient(r *http.Request) (ok bool, public bool) {
+	id, secret, basic := r.BasicAuth()
+	if !basic {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	public = secret == ""
+	if s.ClientID == "" {
+		return true, public
+	}
+	return id == s.ClientID && (public || secret == s.ClientSecret), public
+}
+
+// Check a PKCE code_verifier against the S256 code_challenge
+func verifyChallenge(challenge, verifier string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier != "" && base64.RawURLEncoding.EncodeToString(sum[:]) == challenge
+}
+
+// Write v as a JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Write a JSON response with an ETag, as the Web API does for playlists,
+// answering 304 Not Modified if the request's If-None-Match matches it
+func writeTagged(w http.ResponseWriter, r *http.Request, v interface{}) {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age=0")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(b, '\n'))
+}
+
+// Write a Web API error object; reason is only set for player errors
+func writeError(w http.ResponseWriter, status int, message string, reason string) {
+	e := map[string]interface{}{"status": status, "message": message}
+	if reason != "" {
+		e["reason"] = reason
+	}
+	writeJSON(w, status, map[string]interface{}{"error": e})
+}
+
+// Write an Accounts Service (OAuth2) error
+func writeAuthError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+// Random opaque token used for access tokens, refresh tokens and codes
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,236 @@
+package spotigotest_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo/spotigotest"
+)
+
+// Start a Server, closed when the test ends
+func newServer(t *testing.T) *spotigotest.Server {
+	t.Helper()
+	srv := spotigotest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// POST form to the token endpoint, returning the status and decoded body
+func postToken(t *testing.T, srv *spotigotest.Server, form url.Values) (int, map[string]interface{}) {
+	t.Helper()
+	res, err := srv.Client().PostForm(srv.AccountsURL()+"api/token", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body := make(map[string]interface{})
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, body
+}
+
+// GET path of the Web API with token, returning the response
+func getAPI(t *testing.T, srv *spotigotest.Server, token, path string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, srv.APIURL()+path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res
+}
+
+// Approve a login at the authorize endpoint, returning the code it redirects with
+func authorize(t *testing.T, srv *spotigotest.Server, params url.Values) string {
+	t.Helper()
+	client := *srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	res, err := client.Get(srv.AccountsURL() + "authorize?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d", res.StatusCode)
+	}
+	loc, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loc.Query().Get("state"), params.Get("state"); got != want {
+		t.Errorf("redirect state %q, want %q", got, want)
+	}
+	return loc.Query().Get("code")
+}
+
+func TestClientCredentials(t *testing.T) {
+	srv := newServer(t)
+	srv.ClientID, srv.ClientSecret = "id", "secret"
+
+	status, body := postToken(t, srv, url.Values{"grant_type": {"client_credentials"}, "client_id": {"id"}, "client_secret": {"secret"}})
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+	if body["token_type"] != "Bearer" || body["expires_in"] != float64(3600) {
+		t.Errorf("token response %v", body)
+	}
+	if _, ok := body["refresh_token"]; ok {
+		t.Error("client-credentials token came with a refresh token")
+	}
+	token := body["access_token"].(string)
+	if res := getAPI(t, srv, token, "search?q=x&type=track"); res.StatusCode != http.StatusOK {
+		t.Errorf("search with the token: status %d", res.StatusCode)
+	}
+	if res := getAPI(t, srv, token, "me"); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("me with an app token: status %d, want 401", res.StatusCode)
+	}
+
+	for _, form := range []url.Values{
+		{"grant_type": {"client_credentials"}, "client_id": {"id"}, "client_secret": {"wrong"}},
+		{"grant_type": {"client_credentials"}, "client_id": {"id"}},
+	} {
+		if status, body := postToken(t, srv, form); status != http.StatusUnauthorized || body["error"] != "invalid_client" {
+			t.Errorf("%v: status %d, %v", form, status, body)
+		}
+	}
+	if status, body := postToken(t, srv, url.Values{"grant_type": {"password"}, "client_id": {"id"}, "client_secret": {"secret"}}); status != http.StatusBadRequest || body["error"] != "unsupported_grant_type" {
+		t.Errorf("unknown grant: status %d, %v", status, body)
+	}
+}
+
+func TestAuthorizationCodePKCE(t *testing.T) {
+	srv := newServer(t)
+	verifier := strings.Repeat("v", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	code := authorize(t, srv, url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"id"},
+		"redirect_uri":          {"http://127.0.0.1/callback"},
+		"scope":                 {"user-read-private"},
+		"state":                 {"xyz"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	})
+
+	redeem := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {"id"},
+		"code":          {code},
+		"redirect_uri":  {"http://127.0.0.1/callback"},
+		"code_verifier": {"wrong"},
+	}
+	if status, body := postToken(t, srv, redeem); status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("wrong verifier: status %d, %v", status, body)
+	}
+	redeem.Set("code_verifier", verifier)
+	status, body := postToken(t, srv, redeem)
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %v", status, body)
+	}
+	if body["scope"] != "user-read-private" || body["refresh_token"] == nil {
+		t.Errorf("token response %v", body)
+	}
+	if res := getAPI(t, srv, body["access_token"].(string), "me"); res.StatusCode != http.StatusOK {
+		t.Errorf("me: status %d", res.StatusCode)
+	}
+	if status, _ := postToken(t, srv, redeem); status != http.StatusBadRequest {
+		t.Errorf("code redeemed twice: status %d", status)
+	}
+
+	// A public client refreshes without a secret, keeping the scope
+	status, refreshed := postToken(t, srv, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {"id"},
+		"refresh_token": {body["refresh_token"].(string)},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("refresh: status %d: %v", status, refreshed)
+	}
+	if refreshed["access_token"] == body["access_token"] || refreshed["scope"] != "user-read-private" {
+		t.Errorf("refresh response %v", refreshed)
+	}
+	if status, _ := postToken(t, srv, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"unknown"}}); status != http.StatusBadRequest {
+		t.Errorf("unknown refresh token: status %d", status)
+	}
+}
+
+func TestAuthorizationCodeWithoutPKCE(t *testing.T) {
+	srv := newServer(t)
+	code := authorize(t, srv, url.Values{"response_type": {"code"}, "redirect_uri": {"http://127.0.0.1/callback"}})
+
+	// Public clients must use PKCE
+	form := url.Values{"grant_type": {"authorization_code"}, "client_id": {"id"}, "code": {code}, "redirect_uri": {"http://127.0.0.1/callback"}}
+	if status, _ := postToken(t, srv, form); status != http.StatusUnauthorized {
+		t.Errorf("public client without PKCE: status %d, want 401", status)
+	}
+	form.Set("redirect_uri", "http://127.0.0.1/other")
+	form.Set("client_secret", "secret")
+	if status, _ := postToken(t, srv, form); status != http.StatusBadRequest {
+		t.Errorf("mismatched redirect_uri: status %d, want 400", status)
+	}
+	form.Set("redirect_uri", "http://127.0.0.1/callback")
+	if status, body := postToken(t, srv, form); status != http.StatusOK {
+		t.Errorf("confidential client: status %d: %v", status, body)
+	}
+}
+
+func TestExpireTokens(t *testing.T) {
+	srv := newServer(t)
+	token := srv.UserToken(spotigotest.DefaultUserID)
+	if res := getAPI(t, srv, token, "me"); res.StatusCode != http.StatusOK {
+		t.Fatalf("me: status %d", res.StatusCode)
+	}
+
+	srv.ExpireTokens()
+	if res := getAPI(t, srv, token, "me"); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expired token: status %d, want 401", res.StatusCode)
+	}
+	if res := getAPI(t, srv, srv.UserToken(spotigotest.DefaultUserID), "me"); res.StatusCode != http.StatusOK {
+		t.Errorf("token issued after ExpireTokens: status %d", res.StatusCode)
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	srv := newServer(t)
+	token := srv.UserToken(spotigotest.DefaultUserID)
+
+	srv.FailNext(2, http.StatusTooManyRequests, 1500*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		res := getAPI(t, srv, token, "me")
+		if res.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("request %d: status %d, want 429", i, res.StatusCode)
+		}
+		if got := res.Header.Get("Retry-After"); got != "2" {
+			t.Errorf("request %d: Retry-After %q, want 2", i, got)
+		}
+	}
+	if res := getAPI(t, srv, token, "me"); res.StatusCode != http.StatusOK {
+		t.Errorf("after the failures: status %d", res.StatusCode)
+	}
+
+	// Failures are served ahead of the token check, and only to the Web API
+	srv.FailNext(1, http.StatusInternalServerError, 0)
+	if status, _ := postToken(t, srv, url.Values{"grant_type": {"client_credentials"}, "client_id": {"id"}, "client_secret": {"secret"}}); status != http.StatusOK {
+		t.Errorf("token endpoint: status %d", status)
+	}
+	res := getAPI(t, srv, "bad token", "me")
+	if res.StatusCode != http.StatusInternalServerError || res.Header.Get("Retry-After") != "" {
+		t.Errorf("status %d, Retry-After %q, want 500 without one", res.StatusCode, res.Header.Get("Retry-After"))
+	}
+
+	want := []string{"GET /v1/me", "GET /v1/me", "GET /v1/me", "POST /api/token", "GET /v1/me"}
+	if got := srv.Requests(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Requests() = %v, want %v", got, want)
+	}
+}
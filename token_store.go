@@ -0,0 +1,207 @@
+package spotigo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenStore persists a User's OAuth2 token between runs
+// A User built with a TokenStore saves every refreshed token back to it
+type TokenStore interface {
+	// Load returns the stored token, or nil and no error if nothing is stored
+	Load() (*oauth2.Token, error)
+	// Save replaces the stored token
+	Save(tok *oauth2.Token) error
+}
+
+// Get the scopes granted to a token, as returned by the Accounts Service
+// Tokens loaded from a TokenStore keep the scopes they were saved with
+func TokenScopes(tok *oauth2.Token) []string {
+	if tok == nil {
+		return nil
+	}
+	scopes, _ := tok.Extra("scope").(string)
+	return strings.Fields(scopes)
+}
+
+// storedToken struct- JSON form of a token
+// oauth2.Token does not marshal its extra fields, so the scopes are kept alongside
+type storedToken struct {
+	*oauth2.Token
+	Scope string `json:"scope,omitempty"`
+}
+
+// Encode a token, including its granted scopes, as JSON
+func marshalToken(tok *oauth2.Token) ([]byte, error) {
+	return json.MarshalIndent(storedToken{Token: tok, Scope: strings.Join(TokenScopes(tok), " ")}, "", "  ")
+}
+
+// Decode a token written by marshalToken
+func unmarshalToken(b []byte) (*oauth2.Token, error) {
+	st := storedToken{Token: &oauth2.Token{}}
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+	return withScope(st.Token, st.Scope), nil
+}
+
+// Copy of tok with its granted scopes set to scope
+func withScope(tok *oauth2.Token, scope string) *oauth2.Token {
+	return tok.WithExtra(map[string]interface{}{"scope": scope})
+}
+
+// FileTokenStore stores a token as JSON in a file
+// The file is created with mode 0600, since the token grants access to the account
+type FileTokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Create a FileTokenStore backed by the file at path
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Load the token from the file; a missing file means no token is stored
+func (s *FileTokenStore) Load() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalToken(b)
+}
+
+// Save the token, replacing the file atomically
+func (s *FileTokenStore) Save(tok *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := marshalToken(tok)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// MemoryTokenStore keeps a token in memory, e.g. for tests or for handing
+// tokens to another part of a program
+type MemoryTokenStore struct {
+	mu  sync.Mutex
+	tok *oauth2.Token
+}
+
+// Create an empty MemoryTokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Load the stored token
+func (s *MemoryTokenStore) Load() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tok, nil
+}
+
+// Save the token
+func (s *MemoryTokenStore) Save(tok *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tok = tok
+	return nil
+}
+
+// userTokenSource struct- token source behind every User
+// Keeps the granted scopes across refreshes (Spotify may omit them from a
+// refresh response) and saves each new token to the User's TokenStore
+type userTokenSource struct {
+	src   oauth2.TokenSource
+	store TokenStore
+	// Builds src from a token, to refresh it early when the API rejects it
+	refresh func(*oauth2.Token) oauth2.TokenSource
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+// Get the current token, refreshing it if it has expired
+func (s *userTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tok, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	if s.last != nil && tok.AccessToken == s.last.AccessToken {
+		return s.last, nil
+	}
+
+	if len(TokenScopes(tok)) == 0 {
+		tok = withScope(tok, strings.Join(TokenScopes(s.last), " "))
+	}
+	if s.store != nil {
+		if err := s.store.Save(tok); err != nil {
+			return nil, err
+		}
+	}
+	s.last = tok
+	return tok, nil
+}
+
+// Get the current access token, for the engine
+func (s *userTokenSource) get(ctx context.Context) (string, error) {
+	tok, err := s.Token()
+	if err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
+
+// Force a refresh of an access token the API rejected, such as a revoked
+// one; a token that has already been replaced, or can't be refreshed, is
+// left alone
+func (s *userTokenSource) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refresh == nil || s.last == nil || s.last.AccessToken != token || s.last.RefreshToken == "" {
+		return
+	}
+	expired := *s.last
+	expired.Expiry = time.Now().Add(-time.Minute)
+	s.src = s.refresh(&expired)
+}
+
+// Scopes granted to the current token, without refreshing it
+func (s *userTokenSource) grantedScopes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return TokenScopes(s.last)
+}
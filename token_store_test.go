@@ -0,0 +1,152 @@
+package spotigo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+	"golang.org/x/oauth2"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+	store := spotigo.NewFileTokenStore(path)
+
+	if tok, err := store.Load(); tok != nil || err != nil {
+		t.Fatalf("Load() of a missing file = %v, %v, want nil, nil", tok, err)
+	}
+
+	// An existing file is replaced, and loses any wider mode
+	if err := ioutil.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expiry := time.Now().Add(time.Hour).Round(time.Second)
+	tok := (&oauth2.Token{AccessToken: "access", TokenType: "Bearer", RefreshToken: "refresh", Expiry: expiry}).
+		WithExtra(map[string]interface{}{"scope": "user-read-private user-library-read"})
+	if err := store.Save(tok); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 && runtime.GOOS != "windows" {
+		t.Errorf("mode %v, want 0600", mode)
+	}
+	// The file is written to a temporary file and renamed over the old one
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in the directory, want only the token", len(entries))
+	}
+
+	got, err := spotigo.NewFileTokenStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessToken != "access" || got.RefreshToken != "refresh" || got.TokenType != "Bearer" || !got.Expiry.Equal(expiry) {
+		t.Errorf("loaded %+v", got)
+	}
+	if want := []string{"user-read-private", "user-library-read"}; !reflect.DeepEqual(spotigo.TokenScopes(got), want) {
+		t.Errorf("TokenScopes = %v, want %v", spotigo.TokenScopes(got), want)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Error("no error loading a corrupt file")
+	}
+}
+
+// A client to srv whose token responses leave out the granted scopes, as
+// Spotify's refresh responses may
+func tokensWithoutScope(srv *spotigotest.Server) *http.Client {
+	client := *srv.Client()
+	next := client.Transport
+	client.Transport = spotigo.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var form url.Values
+		if req.URL.Path == "/api/token" && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			b, _ := ioutil.ReadAll(body)
+			form, _ = url.ParseQuery(string(b))
+		}
+		res, err := next.RoundTrip(req)
+		if err != nil || form.Get("grant_type") != "refresh_token" || res.StatusCode != http.StatusOK {
+			return res, err
+		}
+		defer res.Body.Close()
+		tok := make(map[string]interface{})
+		if err := json.NewDecoder(res.Body).Decode(&tok); err != nil {
+			return nil, err
+		}
+		if _, ok := tok["scope"]; !ok {
+			return nil, errors.New("refresh response has no scope to leave out")
+		}
+		delete(tok, "scope")
+		b, _ := json.Marshal(tok)
+		res.Body = ioutil.NopCloser(bytes.NewReader(b))
+		res.ContentLength = int64(len(b))
+		return res, nil
+	})
+	return &client
+}
+
+func TestUserKeepsScopesAcrossRefreshes(t *testing.T) {
+	srv := newServer(t)
+	store := spotigo.NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	u := newUser(t, srv, spotigo.WithHTTPClient(tokensWithoutScope(srv)), spotigo.WithTokenStore(store),
+		spotigo.WithScopes(spotigo.ScopeUserReadPrivate, spotigo.ScopeUserLibraryRead))
+	first, err := u.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.ExpireTokens()
+	if _, err := u.GetCurrentProfile(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRequests(srv, "POST /api/token"); n != 2 {
+		t.Fatalf("%d token requests, want a login and a refresh", n)
+	}
+
+	want := []string{spotigo.ScopeUserReadPrivate, spotigo.ScopeUserLibraryRead}
+	refreshed, err := u.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.AccessToken == first.AccessToken {
+		t.Error("token wasn't refreshed")
+	}
+	if got := spotigo.TokenScopes(refreshed); !reflect.DeepEqual(got, want) {
+		t.Errorf("refreshed token scopes %v, want %v", got, want)
+	}
+	saved, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.AccessToken != refreshed.AccessToken {
+		t.Error("refreshed token wasn't saved")
+	}
+	if got := spotigo.TokenScopes(saved); !reflect.DeepEqual(got, want) {
+		t.Errorf("saved token scopes %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package spotigo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// Tracer starts spans, the way an OpenTelemetry trace.Tracer does; implement
+// it to send the library's spans to your tracing setup
+// Each library call, such as GetSavedTracks, gets a span named after it, and
+// each HTTP request it makes gets a child span named "HTTP GET", "HTTP PUT"
+// and so on, covering any retries
+type Tracer interface {
+	// Start a span as a child of any span in ctx, returning a context
+	// carrying the new span
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key-value pair describing a span
+// Values are strings, ints or bools
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Attribute keys set on spans
+const (
+	// Path template of the endpoint an HTTP span requested, e.g. "tracks/{id}"
+	AttrEndpoint = "spotify.endpoint"
+	// HTTP method of an HTTP span
+	AttrMethod = "http.request.method"
+	// Response status of an HTTP span
+	AttrStatus = "http.response.status_code"
+	// Attempts at an HTTP span's request beyond the first
+	AttrRetries = "spotify.retries"
+	// Items returned by a call span's call: 1 for a call returning a single
+	// item, or the number in its list or page of results; unset for calls
+	// returning none, or that failed
+	AttrItems = "spotify.items"
+	// Whether an HTTP span's response came from the cache given to WithCache:
+	// "hit", "revalidated" after a 304, or "miss"; unset if it wasn't looked up
+	AttrCache = "spotify.cache"
+)
+
+// Trace the calls and requests of a Query or User with tracer
+// By default nothing is traced
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// noopTracer struct- the default Tracer, whose spans do nothing
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// noopSpan struct- a span that records nothing
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// Context key of the HTTP request span in progress
+type requestSpanKey struct{}
+
+// callSpan struct- the span of a library call
+type callSpan struct {
+	span Span
+}
+
+// Start the span of a library call; end it with end or endItems
+func (e *engine) startCall(ctx context.Context, name string) (context.Context, *callSpan) {
+	ctx, span := e.tracer.Start(ctx, name)
+	return ctx, &callSpan{span: span}
+}
+
+// End a call's span, recording the error *errp, if any
+func (s *callSpan) end(errp *error) {
+	if *errp != nil {
+		s.span.RecordError(*errp)
+	}
+	s.span.End()
+}
+
+// End the span of a call that returned items: the error, if any, or else the
+// number of items
+func (s *callSpan) endItems(err error, items int) {
+	if err == nil {
+		s.span.SetAttributes(Attribute{AttrItems, items})
+	}
+	s.end(&err)
+}
+
+// requestSpan struct- the span of one HTTP request, counting its retries
+type requestSpan struct {
+	span    Span
+	retries int32
+
+	mu    sync.Mutex
+	cache string
+}
+
+// Start the span of an HTTP request
+func (e *engine) startRequest(ctx context.Context, method string) (context.Context, *requestSpan) {
+	ctx, span := e.tracer.Start(ctx, "HTTP "+method)
+	s := &requestSpan{span: span}
+	return context.WithValue(ctx, requestSpanKey{}, s), s
+}
+
+// Count a retry of the request whose context is ctx
+func countRetry(ctx context.Context) {
+	if s, ok := ctx.Value(requestSpanKey{}).(*requestSpan); ok {
+		atomic.AddInt32(&s.retries, 1)
+	}
+}
+
+// Record how the cache answered the request
+func (s *requestSpan) setCache(outcome string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = outcome
+}
+
+// End a request's span, recording what was requested and the outcome
+func (s *requestSpan) end(method, reqURL string, res *http.Response, err error) {
+	attrs := []Attribute{{AttrMethod, method}}
+	if u, perr := url.Parse(reqURL); perr == nil {
+		attrs = append(attrs, Attribute{AttrEndpoint, endpointOfPath(u.Path)})
+	}
+	if res != nil {
+		attrs = append(attrs, Attribute{AttrStatus, res.StatusCode})
+	}
+	attrs = append(attrs, Attribute{AttrRetries, int(atomic.LoadInt32(&s.retries))})
+	s.mu.Lock()
+	if s.cache != "" {
+		attrs = append(attrs, Attribute{AttrCache, s.cache})
+	}
+	s.mu.Unlock()
+	s.span.SetAttributes(attrs...)
+	if err != nil {
+		s.span.RecordError(err)
+	}
+	s.span.End()
+}
@@ -0,0 +1,64 @@
+package spotigo_test
+
+import (
+	"testing"
+
+	"github.com/adamgamba/spotigo"
+)
+
+func TestTracingCountsItems(t *testing.T) {
+	srv := newServer(t)
+	srv.AddTrack(newTrack("t1", "Disco Man", "Remi Wolf", 50), newTrack("t2", "Disco", "Remi Wolf", 50))
+	srv.AddPlaylist(spotigo.Playlist{ID: "p1", Name: "Mix"}, "t1", "t2")
+	tr := &recordingTracer{}
+	q := newQuery(t, srv, spotigo.WithTracer(tr))
+
+	if _, err := q.GetTrackByName("Disco Man"); err != nil {
+		t.Fatal(err)
+	}
+	// A playlist is one item, however many tracks it holds
+	if _, err := q.GetPlaylistByURI("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetTracksByURIs("t1", "t2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Search("Disco", spotigo.SearchTypeTrack|spotigo.SearchTypePlaylist); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetTrackByURI("missing"); err == nil {
+		t.Fatal("no error for a missing track")
+	}
+
+	for name, want := range map[string]interface{}{
+		"GetTrackByName":   1,
+		"MatchTrack":       1,
+		"GetPlaylistByURI": 1,
+		"GetTracksByURIs":  2,
+		"Search":           2,
+		"GetTrackByURI":    nil,
+	} {
+		if got := tr.attr(name, spotigo.AttrItems); len(got) != 1 || got[0] != want {
+			t.Errorf("%s items %v, want [%v]", name, got, want)
+		}
+	}
+
+	endpoints := tr.attr("HTTP GET", spotigo.AttrEndpoint)
+	want := []interface{}{"search", "playlists/{id}", "tracks", "search", "tracks/{id}"}
+	if len(endpoints) != len(want) {
+		t.Fatalf("HTTP GET endpoints %v, want %v", endpoints, want)
+	}
+	for i := range want {
+		if endpoints[i] != want[i] {
+			t.Errorf("HTTP GET endpoints %v, want %v", endpoints, want)
+			break
+		}
+	}
+	statuses := tr.attr("HTTP GET", spotigo.AttrStatus)
+	if statuses[0] != 200 || statuses[len(statuses)-1] != 404 {
+		t.Errorf("statuses %v, want 200 first and 404 last", statuses)
+	}
+	if got := tr.attr("HTTP GET", spotigo.AttrItems); got[0] != nil {
+		t.Errorf("HTTP span has items %v", got[0])
+	}
+}
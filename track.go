@@ -72,7 +72,7 @@ func (t *Track) GetName() string {
 
 // Get Track URI
 func (t *Track) GetURI() string {
-	return t.ID
+	return uriOf(EntityTrack, t.ID)
 }
 
 // Get Track Duration in milliseconds
@@ -87,14 +87,14 @@ func (t *Track) GetAlbumName() string {
 
 // Get Album URI for Track
 func (t *Track) GetAlbumURI() string {
-	return t.Album.ID
+	return uriOf(EntityAlbum, t.Album.ID)
 }
 
 // Get all Artist URIs for Track
 func (t *Track) GetArtistURIs() []string {
 	uris := make([]string, 0)
 	for _, artist := range t.Artists {
-		uris = append(uris, artist.ID)
+		uris = append(uris, uriOf(EntityArtist, artist.ID))
 	}
 	return uris
 }
@@ -1,280 +1,202 @@
-// Package spotify provides utilties for interfacing
+// Package spotigo provides utilities for interfacing
 // with Spotify's Web API.
 package spotigo
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"strconv"
-	"time"
+	"context"
 
-	"github.com/pkg/browser"
 	"golang.org/x/oauth2"
 )
 
 // User struct- used for making queries that require
 // authentication access to a User's account
 type User struct {
-	http    *http.Client
+	engine  *engine
 	baseURL string
 
-	autoRetry      bool
-	acceptLanguage string
-
 	auth   authenticator
+	opts   options
+	tokens *userTokenSource
+	// Requested scopes
 	scopes scope
 }
 
-var (
-	userExists = false
-	ch         = make(chan *User)
-)
-
 // Get all scopes for a user
+// These are the scopes the user actually granted, which may be fewer than
+// were requested; the requested scopes are returned if the token doesn't say
 // See scopes.go for details on Scopes
 func (u *User) GetScopes() []string {
-	return u.scopes.Scopes
+	if granted := u.tokens.grantedScopes(); len(granted) > 0 {
+		return granted
+	}
+	return append([]string(nil), u.scopes.Scopes...)
 }
 
 // Create and authenticate new User
 // Defaults to using all (relevant) scopes if none are declared
-func NewUser(client_key, secret_key string, scopes ...string) (*User, bool) {
-	ok := true
+func NewUser(client_key, secret_key string, scopes ...string) (*User, error) {
+	return NewUserContext(context.Background(), client_key, secret_key, scopes...)
+}
 
-	if len(scopes) == 0 {
-		scopes = []string{
-			ScopeUserModifyPlaybackState,
-			ScopeUserReadPlaybackState,
-			ScopeUserLibraryModify,
-			ScopeUserLibraryRead,
-			ScopeUserFollowModify,
-			ScopePlaylistModifyPublic,
-			ScopePlaylistModifyPrivate,
-			ScopeUserFollowRead}
-	}
+// NewUserContext is NewUser with a context
+// Cancelling ctx stops waiting for the login to complete
+func NewUserContext(ctx context.Context, client_key, secret_key string, scopes ...string) (*User, error) {
+	return NewUserWithOptionsContext(ctx, client_key, secret_key, WithScopes(scopes...))
+}
 
-	const redirectURI = "http://localhost:8080/callback"
-	auth := newAuthenticator(
-		"http://localhost:8080/callback", scopes...)
+// Create and authenticate new User, configured by opts
+// Scopes are set with WithScopes; defaults to using all (relevant) scopes if none are declared
+func NewUserWithOptions(client_key, secret_key string, opts ...Option) (*User, error) {
+	return NewUserWithOptionsContext(context.Background(), client_key, secret_key, opts...)
+}
 
-	state := ""
+// NewUserWithOptionsContext is NewUserWithOptions with a context
+// Cancelling ctx stops waiting for the login to complete
+// With WithTokenStore, a stored token is used instead of logging in, and the
+// token from a new login is saved to the store
+func NewUserWithOptionsContext(ctx context.Context, client_key, secret_key string, opts ...Option) (*User, error) {
+	return newUser(ctx, client_key, secret_key, false, opts)
+}
 
-	// Define callback function
-	completeAuth := func(w http.ResponseWriter, r *http.Request) {
-		tok, err := auth.token(state, r)
-		if err != nil {
-			http.Error(w, "Couldn't get token", http.StatusForbidden)
-			ok = false
-		}
-		if st := r.FormValue("state"); st != state {
-			http.NotFound(w, r)
-			// log.Fatalf("State mismatch: %s != %s\n", st, state)
-			ok = false
-		}
-		// use the token to get an authenticated client
-		client := auth.newClient(tok)
+// Create and authenticate new User with the Authorization Code with PKCE flow
+// Only the client ID is needed, so public clients (desktop and command-line
+// tools) don't have to ship the client secret
+// Accepts the same options as NewUserWithOptions
+func NewUserPKCE(client_id string, opts ...Option) (*User, error) {
+	return NewUserPKCEContext(context.Background(), client_id, opts...)
+}
 
-		fmt.Fprintf(w, "Login Completed!")
-		ch <- &client
-	}
+// NewUserPKCEContext is NewUserPKCE with a context
+// Cancelling ctx stops waiting for the login to complete
+func NewUserPKCEContext(ctx context.Context, client_id string, opts ...Option) (*User, error) {
+	return newUser(ctx, client_id, "", true, opts)
+}
 
-	// First start an HTTP server
-	// Only set HTTP callbacks for the first user
-	if !userExists {
-		http.HandleFunc("/callback", completeAuth)
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		})
+// Log in a new User, using PKCE instead of the client secret if pkce is set
+func newUser(ctx context.Context, client_key, secret_key string, pkce bool, opts []Option) (*User, error) {
+	o := newOptions(opts)
+	if len(o.scopes) == 0 {
+		o.scopes = defaultScopes()
 	}
-	go http.ListenAndServe(":8080", nil)
 
+	auth := newAuthenticator(o.redirectURL, o)
 	auth.setAuthInfo(client_key, secret_key)
 
-	// Use if you want login dialog to show every time
-	// url := auth.authURLWithDialog(state)
-
-	// Use if you want login dialog to show only when necessary
-	url := auth.authURL(state)
-
-	browser.OpenURL(url)
-
-	// wait for auth to complete
-	user := <-ch
-	user.auth = auth
-	user.scopes = scope{Scopes: scopes}
-
-	userExists = true
-
-	return user, ok
-}
-
-// Source for everything below: https://github.com/zmb3/spotify/
-
-// errorStruct represents an error returned by the Spotify Web API.
-type errorStruct struct {
-	// A short description of the error.
-	Message string `json:"message"`
-	// The HTTP status code.
-	Status int `json:"status"`
-}
+	if o.tokenStore != nil {
+		tok, err := o.tokenStore.Load()
+		if err != nil {
+			return nil, err
+		}
+		if tok != nil {
+			return auth.newUser(tok, o), nil
+		}
+	}
 
-// return error message
-func (e errorStruct) Error() string {
-	return e.Message
+	return login(ctx, auth, o, pkce)
 }
 
-// decodeError decodes an Error from an io.Reader.
-func (u *User) decodeError(resp *http.Response) error {
-	responseBody, err := ioutil.ReadAll(resp.Body)
+// Log in through the browser, or o's auth prompt, and build the User
+func login(ctx context.Context, auth authenticator, o options, pkce bool) (*User, error) {
+	state, err := newState()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if len(responseBody) == 0 {
-		return fmt.Errorf("spotify: HTTP %d: %s (body empty)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	var authOpts, exchangeOpts []oauth2.AuthCodeOption
+	if pkce {
+		verifier, err := newCodeVerifier()
+		if err != nil {
+			return nil, err
+		}
+		authOpts = challengeParams(verifier)
+		exchangeOpts = append(exchangeOpts, verifierParam(verifier))
 	}
 
-	buf := bytes.NewBuffer(responseBody)
-
-	var e struct {
-		E errorStruct `json:"error"`
+	var tok *oauth2.Token
+	if o.authPrompt != nil {
+		tok, err = auth.promptLogin(ctx, o.authPrompt, state, o.authTimeout, authOpts, exchangeOpts)
+	} else {
+		tok, err = auth.browserLogin(ctx, state, o.authTimeout, authOpts, exchangeOpts)
 	}
-	err = json.NewDecoder(buf).Decode(&e)
 	if err != nil {
-		return fmt.Errorf("spotify: couldn't decode error: (%d) [%s]", len(responseBody), responseBody)
+		return nil, err
 	}
 
-	if e.E.Message == "" {
-		// Some errors will result in there being a useful status-code but an
-		// empty message, which will confuse the user (who only has access to
-		// the message and not the code). An example of this is when we send
-		// some of the arguments directly in the HTTP query and the URL ends-up
-		// being too long.
-
-		e.E.Message = fmt.Sprintf("spotify: unexpected HTTP %d: %s (empty error)",
-			resp.StatusCode, http.StatusText(resp.StatusCode))
+	if o.tokenStore != nil {
+		if err := o.tokenStore.Save(tok); err != nil {
+			return nil, err
+		}
 	}
 
-	return e.E
-}
-
-// shouldRetry determines whether the status code indicates that the
-// previous operation should be retried at a later time
-func shouldRetry(status int) bool {
-	return status == http.StatusAccepted || status == http.StatusTooManyRequests
+	// use the token to get an authenticated client
+	return auth.newUser(tok, o), nil
 }
 
-// isFailure determines whether the code indicates failure
-func isFailure(code int, validCodes []int) bool {
-	for _, item := range validCodes {
-		if item == code {
-			return false
-		}
+// Rebuild a User from a token saved by an earlier login, without logging in
+// The token is refreshed when it expires; with WithTokenStore, each refreshed
+// token is saved to the store
+// Pass an empty secret_key for tokens from NewUserPKCE
+func NewUserFromToken(client_key, secret_key string, tok *oauth2.Token, opts ...Option) (*User, error) {
+	if tok == nil || (tok.AccessToken == "" && tok.RefreshToken == "") {
+		return nil, invalidInput("token has neither an access token nor a refresh token")
 	}
-	return true
-}
 
-// `execute` executes a non-GET request. `needsStatus` describes other HTTP
-// status codes that will be treated as success. Note that we allow all 200s
-// even if there are additional success codes that represent success.
-func (u *User) execute(req *http.Request, result interface{}, needsStatus ...int) error {
-	if u.acceptLanguage != "" {
-		req.Header.Set("Accept-Language", u.acceptLanguage)
+	o := newOptions(opts)
+	if len(o.scopes) == 0 {
+		o.scopes = TokenScopes(tok)
 	}
-	for {
-		resp, err := u.http.Do(req)
-		if err != nil {
-			return err
-		}
-		defer resp.Body.Close()
 
-		if u.autoRetry && shouldRetry(resp.StatusCode) {
-			time.Sleep(retryDuration(resp))
-			continue
-		}
-		if resp.StatusCode == http.StatusNoContent {
-			return nil
-		}
-		if (resp.StatusCode >= 300 ||
-			resp.StatusCode < 200) &&
-			isFailure(resp.StatusCode, needsStatus) {
-			return u.decodeError(resp)
-		}
-
-		if result != nil {
-			if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-				return err
-			}
-		}
-		break
-	}
-	return nil
+	auth := newAuthenticator("", o)
+	auth.setAuthInfo(client_key, secret_key)
+	return auth.newUser(tok, o), nil
 }
 
-// get retry duration
-func retryDuration(resp *http.Response) time.Duration {
-	raw := resp.Header.Get("Retry-After")
-	if raw == "" {
-		return time.Second * 5
-	}
-	seconds, err := strconv.ParseInt(raw, 10, 32)
-	if err != nil {
-		return time.Second * 5
-	}
-	return time.Duration(seconds) * time.Second
+// Scopes requested when none are declared
+func defaultScopes() []string {
+	return []string{
+		ScopeUserModifyPlaybackState,
+		ScopeUserReadPlaybackState,
+		ScopeUserLibraryModify,
+		ScopeUserLibraryRead,
+		ScopeUserFollowModify,
+		ScopePlaylistModifyPublic,
+		ScopePlaylistModifyPrivate,
+		ScopeUserFollowRead}
 }
 
-// execute a get request
-func (u *User) get(url string, result interface{}) error {
-	for {
-		req, err := http.NewRequest("GET", url, nil)
-		if u.acceptLanguage != "" {
-			req.Header.Set("Accept-Language", u.acceptLanguage)
-		}
-		if err != nil {
-			return err
-		}
-		resp, err := u.http.Do(req)
-		if err != nil {
-			return err
-		}
-
-		defer resp.Body.Close()
+// Get the User's current OAuth2 token, refreshing it first if it has expired
+// The token includes the refresh token and the granted scopes (see TokenScopes),
+// and can be persisted and passed to NewUserFromToken later
+func (u *User) Token() (*oauth2.Token, error) {
+	return u.token()
+}
 
-		if resp.StatusCode == 429 && u.autoRetry {
-			time.Sleep(retryDuration(resp))
-			continue
-		}
-		if resp.StatusCode == http.StatusNoContent {
-			return nil
-		}
-		if resp.StatusCode != http.StatusOK {
-			return u.decodeError(resp)
-		}
+// Log the user in again to grant extra scopes, keeping the ones already granted
+// Returns a new User with the wider grant; u keeps working with its old token
+// The login uses the same client and options (redirect URL, auth prompt,
+// token store) as u, and PKCE if u was created without a client secret
+func (u *User) Reauthorize(scopes ...string) (*User, error) {
+	return u.ReauthorizeContext(context.Background(), scopes...)
+}
 
-		err = json.NewDecoder(resp.Body).Decode(result)
-		if err != nil {
-			return err
+// ReauthorizeContext is Reauthorize with a context
+func (u *User) ReauthorizeContext(ctx context.Context, scopes ...string) (*User, error) {
+	o := u.opts
+	o.scopes = nil
+	seen := make(map[string]bool)
+	for _, s := range append(u.GetScopes(), scopes...) {
+		if !seen[s] {
+			seen[s] = true
+			o.scopes = append(o.scopes, s)
 		}
-
-		break
 	}
 
-	return nil
+	auth := newAuthenticator(o.redirectURL, o)
+	auth.setAuthInfo(u.auth.config.ClientID, u.auth.config.ClientSecret)
+	return login(ctx, auth, o, u.auth.config.ClientSecret == "")
 }
 
 // token gets the client's current token.
 func (u *User) token() (*oauth2.Token, error) {
-	transport, ok := u.http.Transport.(*oauth2.Transport)
-	if !ok {
-		return nil, errors.New("spotify: client not backed by oauth2 transport")
-	}
-	t, err := transport.Source.Token()
-	if err != nil {
-		return nil, err
-	}
-	return t, nil
+	return u.tokens.Token()
 }
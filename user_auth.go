@@ -5,7 +5,9 @@ import (
 	"crypto/tls"
 	"errors"
 	"net/http"
+	"net/url"
 	"os"
+	"sync"
 
 	"golang.org/x/oauth2"
 )
@@ -13,51 +15,68 @@ import (
 // Source: https://github.com/zmb3/spotify/
 
 const (
-	// AuthURL is the URL to Spotify Accounts Service's OAuth2 endpoint.
-	authURL = "https://accounts.spotify.com/authorize"
-	// TokenURL is the URL to the Spotify Accounts Service's OAuth2
-	// token endpoint.
-	tokenURL = "https://accounts.spotify.com/api/token"
+	// AuthURL is the path of the Spotify Accounts Service's OAuth2 endpoint,
+	// relative to the Accounts base URL.
+	authURL = "authorize"
+	// TokenURL is the path of the Spotify Accounts Service's OAuth2
+	// token endpoint, relative to the Accounts base URL.
+	tokenURL = "api/token"
 )
 
 // authenticator provides convenience functions for implementing the OAuth2 flow.
 type authenticator struct {
 	config  *oauth2.Config
 	context context.Context
+	baseURL string
+	// Client the token requests of context are sent with
+	httpClient *http.Client
 }
 
 // NewAuthenticator creates an authenticator which is used to implement the
 // OAuth2 authorization flow.  The redirectURL must exactly match one of the
 // URLs specified in your Spotify developer account.
 //
-func newAuthenticator(redirectURL string, scopes ...string) authenticator {
+// The Accounts and Web API base URLs and the HTTP client are taken from o.
+func newAuthenticator(redirectURL string, o options) authenticator {
 	cfg := &oauth2.Config{
 		ClientID:     os.Getenv("SPOTIFY_ID"),
 		ClientSecret: os.Getenv("SPOTIFY_SECRET"),
 		RedirectURL:  redirectURL,
-		Scopes:       scopes,
+		Scopes:       o.scopes,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  authURL,
-			TokenURL: tokenURL,
+			AuthURL:  o.accountsURL + authURL,
+			TokenURL: o.accountsURL + tokenURL,
 		},
 	}
 
-	// disable HTTP/2 for DefaultClient, see: https://github.com/zmb3/spotify/issues/20
-	tr := &http.Transport{
-		TLSNextProto: map[string]func(authority string, c *tls.Conn) http.RoundTripper{},
+	httpClient := o.httpClient
+	if httpClient == nil {
+		// disable HTTP/2 for DefaultClient, see: https://github.com/zmb3/spotify/issues/20
+		tr := &http.Transport{
+			TLSNextProto: map[string]func(authority string, c *tls.Conn) http.RoundTripper{},
+		}
+		httpClient = &http.Client{Transport: tr}
 	}
-	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: tr})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
 	return authenticator{
-		config:  cfg,
-		context: ctx,
+		config:     cfg,
+		context:    ctx,
+		baseURL:    o.apiURL,
+		httpClient: httpClient,
 	}
 }
 
 // SetAuthInfo overwrites the client ID and secret key used by the authenticator.
 // You can use this if you don't want to store this information in environment variables.
+// An empty secretKey makes this a public (PKCE) client, which sends its ID in
+// the token request body instead of authenticating with a secret.
 func (a *authenticator) setAuthInfo(clientID, secretKey string) {
 	a.config.ClientID = clientID
 	a.config.ClientSecret = secretKey
+	a.config.Endpoint.AuthStyle = oauth2.AuthStyleAutoDetect
+	if secretKey == "" {
+		a.config.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
 }
 
 // AuthURL returns a URL to the the Spotify Accounts Service's OAuth2 endpoint.
@@ -65,20 +84,32 @@ func (a *authenticator) setAuthInfo(clientID, secretKey string) {
 // State is a token to protect the user from CSRF attacks.  You should pass the
 // same state to `Token`, where it will be validated.  For more info, refer to
 // http://tools.ietf.org/html/rfc6749#section-10.12.
-func (a authenticator) authURL(state string) string {
-	return a.config.AuthCodeURL(state)
+//
+// opts add parameters to the URL, e.g. a PKCE code challenge.
+func (a authenticator) authURL(state string, opts ...oauth2.AuthCodeOption) string {
+	return a.config.AuthCodeURL(state, opts...)
 }
 
 // AuthURLWithDialog returns the same URL as AuthURL, but sets show_dialog to true
-func (a authenticator) authURLWithDialog(state string) string {
-	return a.config.AuthCodeURL(state, oauth2.SetAuthURLParam("show_dialog", "true"))
+func (a authenticator) authURLWithDialog(state string, opts ...oauth2.AuthCodeOption) string {
+	opts = append(opts, oauth2.SetAuthURLParam("show_dialog", "true"))
+	return a.config.AuthCodeURL(state, opts...)
 }
 
 // Token pulls an authorization code from an HTTP request and attempts to exchange
 // it for an access token.  The standard use case is to call Token from the handler
 // that handles requests to your application's redirect URL.
-func (a authenticator) token(state string, r *http.Request) (*oauth2.Token, error) {
-	values := r.URL.Query()
+//
+// opts add parameters to the exchange, e.g. a PKCE code verifier.
+// The exchange is cancelled if the request is.
+func (a authenticator) token(state string, r *http.Request, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	return a.exchange(r.Context(), state, r.URL.Query(), opts...)
+}
+
+// Exchange checks the query parameters of a redirect to the redirect URL and
+// exchanges their authorization code for an access token, sending the token
+// request with a's HTTP client and ctx.
+func (a authenticator) exchange(ctx context.Context, state string, values url.Values, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
 	if e := values.Get("error"); e != "" {
 		return nil, errors.New("spotify: auth failed - " + e)
 	}
@@ -90,14 +121,56 @@ func (a authenticator) token(state string, r *http.Request) (*oauth2.Token, erro
 	if actualState != state {
 		return nil, errors.New("spotify: redirect state parameter doesn't match")
 	}
-	return a.config.Exchange(a.context, code)
+	return a.config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, a.httpClient), code, opts...)
+}
+
+// NewUser creates a User that will use the specified token for its API requests.
+// Refreshed tokens are saved to o's token store, if any.
+func (a authenticator) newUser(token *oauth2.Token, o options) *User {
+	o.shareRateLimiter(a.config.ClientID)
+	refresh := func(tok *oauth2.Token) oauth2.TokenSource {
+		return a.config.TokenSource(a.context, tok)
+	}
+	src := &userTokenSource{
+		src:     refresh(token),
+		store:   o.tokenStore,
+		refresh: refresh,
+		last:    token,
+	}
+	e := newEngine(a.httpClient, o)
+	e.token = src.get
+	e.invalidate = src.invalidate
+	e.identity = currentUserID(e, a.baseURL)
+	return &User{
+		engine:  e,
+		baseURL: a.baseURL,
+		auth:    a,
+		opts:    o,
+		tokens:  src,
+		scopes:  scope{Scopes: o.scopes},
+	}
 }
 
-// NewClient creates a Client that will use the specified access token for its API requests.
-func (a authenticator) newClient(token *oauth2.Token) User {
-	client := a.config.Client(a.context, token)
-	return User{
-		http:    client,
-		baseURL: "https://api.spotify.com/v1/",
+// Look up the ID of the user whose token e sends, once it is first needed,
+// and keep it
+// Failed lookups are retried the next time
+func currentUserID(e *engine, baseURL string) func(ctx context.Context) (string, error) {
+	var (
+		mu sync.Mutex
+		id string
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if id == "" {
+			var profile struct {
+				ID string `json:"id"`
+			}
+			if err := e.get(ctx, baseURL+"me", &profile); err != nil {
+				return "", err
+			}
+			id = "user " + profile.ID
+		}
+		return id, nil
 	}
 }
@@ -0,0 +1,239 @@
+package spotigo
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// WebAuth runs the User login flow inside a web service, for many accounts
+// LoginHandler sends a visitor to Spotify to log in, and CallbackHandler
+// serves the redirect URL, turning each completed login into a User
+type WebAuth struct {
+	auth   authenticator
+	o      options
+	pkce   bool
+	states StateStore
+}
+
+// StateStore keeps the state of each login started by LoginHandler until its
+// redirect reaches CallbackHandler
+// The request and response are passed through so that an implementation can
+// bind the state to the visitor's session, e.g. with a cookie
+type StateStore interface {
+	// Save records a new login's state with its PKCE code verifier, which is
+	// empty when PKCE isn't used
+	Save(w http.ResponseWriter, r *http.Request, state, verifier string) error
+	// Take returns the verifier saved with state and forgets the state;
+	// ok is false if the state is unknown or has expired
+	Take(w http.ResponseWriter, r *http.Request, state string) (verifier string, ok bool)
+}
+
+// Create a WebAuth for a web service whose callback is served at redirectURL
+// redirectURL must be registered in the app's settings on the Spotify
+// Developer Dashboard
+// An empty secret_key makes this a public client, which logs in with PKCE
+// Logins are remembered for 10 minutes in a MemoryStateStore behind a
+// CookieStateStore, so each must finish in the browser that started it, unless
+// WithStateStore is given; WithTokenStore is ignored, since every login is a
+// different account
+func NewWebAuth(client_key, secret_key, redirectURL string, opts ...Option) *WebAuth {
+	o := newOptions(opts)
+	if len(o.scopes) == 0 {
+		o.scopes = defaultScopes()
+	}
+	o.tokenStore = nil
+
+	auth := newAuthenticator(redirectURL, o)
+	auth.setAuthInfo(client_key, secret_key)
+
+	states := o.stateStore
+	if states == nil {
+		secure := strings.HasPrefix(strings.ToLower(redirectURL), "https://")
+		states = NewCookieStateStore(NewMemoryStateStore(10*time.Minute), secure)
+	}
+	return &WebAuth{auth: auth, o: o, pkce: secret_key == "", states: states}
+}
+
+// Keep the state of logins started by a WebAuth in store
+// A store that doesn't bind each state to the browser that started the
+// login, such as a bare MemoryStateStore, lets anyone who gets a visitor to
+// follow their redirect log the visitor in to the wrong account
+func WithStateStore(store StateStore) Option {
+	return func(o *options) {
+		o.stateStore = store
+	}
+}
+
+// LoginHandler redirects each request to Spotify's login page
+func (a *WebAuth) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, err := newState()
+		if err != nil {
+			http.Error(w, "Couldn't start login", http.StatusInternalServerError)
+			return
+		}
+
+		var opts []oauth2.AuthCodeOption
+		verifier := ""
+		if a.pkce {
+			if verifier, err = newCodeVerifier(); err != nil {
+				http.Error(w, "Couldn't start login", http.StatusInternalServerError)
+				return
+			}
+			opts = challengeParams(verifier)
+		}
+
+		if err := a.states.Save(w, r, state, verifier); err != nil {
+			http.Error(w, "Couldn't start login", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, a.auth.authURL(state, opts...), http.StatusFound)
+	})
+}
+
+// CallbackHandler serves the redirect URL: it exchanges the login's code for
+// a token and passes the new User and its token to onLogin, with the request,
+// so the User can be tied to the visitor's session, e.g. with a cookie set on w
+// next then serves the request, e.g. to redirect to the app; if next is nil a
+// short confirmation is written. A nil onLogin is skipped
+// Redirects with an unknown state are rejected with 400 Bad Request
+func (a *WebAuth) CallbackHandler(onLogin func(w http.ResponseWriter, r *http.Request, user *User, tok *oauth2.Token), next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := r.URL.Query().Get("state")
+		verifier, ok := a.states.Take(w, r, state)
+		if !ok {
+			http.Error(w, "Unknown login", http.StatusBadRequest)
+			return
+		}
+
+		var opts []oauth2.AuthCodeOption
+		if verifier != "" {
+			opts = append(opts, verifierParam(verifier))
+		}
+		tok, err := a.auth.token(state, r, opts...)
+		if err != nil {
+			http.Error(w, "Couldn't get token", http.StatusForbidden)
+			return
+		}
+
+		if onLogin != nil {
+			onLogin(w, r, a.auth.newUser(tok, a.o), tok)
+		}
+		if next == nil {
+			fmt.Fprintf(w, "Login Completed!")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Name of the cookie binding a login to the browser that started it
+const stateCookie = "spotigo_login_state"
+
+// CookieStateStore binds each login to the browser that started it, so a
+// login's redirect can't be completed by anyone else
+// Save sets an HttpOnly, SameSite=Lax cookie holding the state, and Take
+// only accepts a state matching the cookie, before passing it to the
+// wrapped StateStore. A browser has one pending login at a time; starting
+// another replaces it
+type CookieStateStore struct {
+	next   StateStore
+	secure bool
+}
+
+// Create a CookieStateStore keeping states in next
+// secure marks the cookie Secure, for a redirect URL served over HTTPS
+func NewCookieStateStore(next StateStore, secure bool) *CookieStateStore {
+	return &CookieStateStore{next: next, secure: secure}
+}
+
+// Save a login's state and set the browser's cookie
+func (s *CookieStateStore) Save(w http.ResponseWriter, r *http.Request, state, verifier string) error {
+	if err := s.next.Save(w, r, state, verifier); err != nil {
+		return err
+	}
+	s.setCookie(w, state, 0)
+	return nil
+}
+
+// Take a login's state if it matches the browser's cookie, clearing the cookie
+func (s *CookieStateStore) Take(w http.ResponseWriter, r *http.Request, state string) (string, bool) {
+	c, err := r.Cookie(stateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(c.Value), []byte(state)) != 1 {
+		return "", false
+	}
+	s.setCookie(w, "", -1)
+	return s.next.Take(w, r, state)
+}
+
+// Set the state cookie; a negative maxAge deletes it
+func (s *CookieStateStore) setCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   s.secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// MemoryStateStore keeps login states in memory for a limited time
+// States aren't tied to a session, so any browser holding the redirect can
+// complete the login; wrap it in a CookieStateStore, as NewWebAuth does,
+// unless logins are bound to the browser some other way
+type MemoryStateStore struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	states map[string]pendingState
+}
+
+// A saved login state
+type pendingState struct {
+	verifier string
+	expiry   time.Time
+}
+
+// Create a MemoryStateStore whose states expire after ttl
+func NewMemoryStateStore(ttl time.Duration) *MemoryStateStore {
+	return &MemoryStateStore{ttl: ttl, states: make(map[string]pendingState)}
+}
+
+// Save a login's state, dropping any expired ones
+func (s *MemoryStateStore) Save(w http.ResponseWriter, r *http.Request, state, verifier string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, p := range s.states {
+		if now.After(p.expiry) {
+			delete(s.states, k)
+		}
+	}
+	s.states[state] = pendingState{verifier: verifier, expiry: now.Add(s.ttl)}
+	return nil
+}
+
+// Take a login's state
+func (s *MemoryStateStore) Take(w http.ResponseWriter, r *http.Request, state string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.states[state]
+	if !ok {
+		return "", false
+	}
+	delete(s.states, state)
+	if time.Now().After(p.expiry) {
+		return "", false
+	}
+	return p.verifier, true
+}
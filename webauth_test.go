@@ -0,0 +1,157 @@
+package spotigo_test
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adamgamba/spotigo"
+	"github.com/adamgamba/spotigo/spotigotest"
+	"golang.org/x/oauth2"
+)
+
+// webApp struct- a web service logging visitors in through a WebAuth
+type webApp struct {
+	*httptest.Server
+	mu     sync.Mutex
+	logins []string
+}
+
+// Serve a WebAuth's handlers at /login and /callback, recording each login's
+// user ID unless onLogin is false
+func newWebApp(t *testing.T, srv *spotigotest.Server, onLogin bool, opts ...spotigo.Option) *webApp {
+	t.Helper()
+	app := &webApp{}
+	mux := http.NewServeMux()
+	app.Server = httptest.NewServer(mux)
+	t.Cleanup(app.Close)
+
+	opts = append([]spotigo.Option{spotigo.WithScopes(spotigo.ScopeUserReadPrivate)}, opts...)
+	auth := spotigo.NewWebAuth("id", "secret", app.URL+"/callback", testOptions(srv, opts...)...)
+	var hook func(http.ResponseWriter, *http.Request, *spotigo.User, *oauth2.Token)
+	if onLogin {
+		hook = func(w http.ResponseWriter, r *http.Request, user *spotigo.User, tok *oauth2.Token) {
+			profile, err := user.GetCurrentProfile()
+			if err != nil {
+				t.Error(err)
+			}
+			app.mu.Lock()
+			defer app.mu.Unlock()
+			app.logins = append(app.logins, profile.ID)
+		}
+	}
+	mux.Handle("/login", auth.LoginHandler())
+	mux.Handle("/callback", auth.CallbackHandler(hook, http.RedirectHandler("/done", http.StatusFound)))
+	mux.HandleFunc("/done", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("done"))
+	})
+	return app
+}
+
+// A browser with its own cookies; if follow is false it stops at the
+// redirect to the app's callback
+func newBrowser(t *testing.T, follow bool) *http.Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &http.Client{Jar: jar, Timeout: 10 * time.Second}
+	if !follow {
+		c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if req.URL.Path == "/callback" {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		}
+	}
+	return c
+}
+
+// Start a login in browser, returning the callback URL Spotify redirected to
+func startLogin(t *testing.T, app *webApp, browser *http.Client) string {
+	t.Helper()
+	res, err := browser.Get(app.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	callback := res.Header.Get("Location")
+	if !strings.HasPrefix(callback, app.URL+"/callback?") {
+		t.Fatalf("redirected to %q, want the callback", callback)
+	}
+	return callback
+}
+
+func TestWebAuthLogin(t *testing.T) {
+	srv := newServer(t)
+	app := newWebApp(t, srv, true)
+
+	res, err := newBrowser(t, true).Get(app.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.Request.URL.Path != "/done" {
+		t.Errorf("login ended at %s with status %d", res.Request.URL, res.StatusCode)
+	}
+	if len(app.logins) != 1 || app.logins[0] != spotigotest.DefaultUserID {
+		t.Errorf("logins %v", app.logins)
+	}
+}
+
+func TestWebAuthStateBoundToBrowser(t *testing.T) {
+	srv := newServer(t)
+	app := newWebApp(t, srv, true)
+
+	// An attacker starts a login and sends the victim its redirect
+	callback := startLogin(t, app, newBrowser(t, false))
+	res, err := newBrowser(t, true).Get(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("redirect in another browser: status %d, want 400", res.StatusCode)
+	}
+	if len(app.logins) != 0 {
+		t.Errorf("logins %v, want none", app.logins)
+	}
+
+	// The browser that started a login can finish it, once
+	browser := newBrowser(t, false)
+	callback = startLogin(t, app, browser)
+	for i, want := range []int{http.StatusOK, http.StatusBadRequest} {
+		res, err := browser.Get(callback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Errorf("callback %d: status %d, want %d", i, res.StatusCode, want)
+		}
+	}
+	if len(app.logins) != 1 {
+		t.Errorf("logins %v, want one", app.logins)
+	}
+}
+
+func TestWebAuthUnboundStateStore(t *testing.T) {
+	srv := newServer(t)
+	app := newWebApp(t, srv, false, spotigo.WithStateStore(spotigo.NewMemoryStateStore(time.Minute)))
+
+	// Without the cookie any browser may finish the login, and a nil hook
+	// goes straight to next
+	callback := startLogin(t, app, newBrowser(t, false))
+	res, err := newBrowser(t, true).Get(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.Request.URL.Path != "/done" {
+		t.Errorf("login ended at %s with status %d", res.Request.URL, res.StatusCode)
+	}
+}